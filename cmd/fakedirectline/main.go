@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3/fake"
+)
+
+// A local Direct Line service for offline development, point the api to it with
+// COPILOT_BASE_URL=http://localhost:3978 COPILOT_SECRET=fake-secret
+func main() {
+	addr := flag.String("addr", ":3978", "listen address")
+	secret := flag.String("secret", fake.DefaultSecret, "accepted Direct Line secret, empty accepts any bearer")
+	delay := flag.Duration("delay", 2*time.Second, "delay of the bot replies")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	bot := fake.NewBot(
+		fake.WithSecret(*secret),
+		fake.WithResponder(func(directlinev3.Activity) fake.Reply {
+			return fake.Reply{Text: fake.SampleAssessment, Delay: *delay}
+		}),
+	)
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: bot,
+	}
+
+	go func() {
+		log.Printf("Fake Direct Line is listening on %s", *addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen: %s\n", err)
+		}
+	}()
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("Fake Direct Line forced to shutdown:", err)
+	}
+}
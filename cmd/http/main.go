@@ -21,13 +21,15 @@ func main() {
 	defer stop()
 
 	r := gin.Default()
+	// Let the handlers pass gin.Context down so that a cancelled request also cancels the upstream calls
+	r.ContextWithFallback = true
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{originDomain}, // Replace with your frontend's URL
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: true,
 	}))
 	r.GET("/", func(c *gin.Context) {
@@ -48,7 +50,8 @@ func main() {
 	if c.App.IsCacheOn != config.CACHE_ON {
 		c.Redis = nil
 	}
-	_ = initializeHandlers(ctx, r.Group("/v1"), db, c.Redis)
+	app := initializeApp(ctx, r.Group("/v1"), db, c.Redis, c.Auth, c.Copilot, c.OpenAI, c.Assessment)
+	app.Workers.AssessmentRunner.Start(ctx)
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%s", c.App.Port),
 		Handler: r.Handler(),
@@ -74,5 +77,9 @@ func main() {
 		log.Fatal("Server forced to shutdown: ", err)
 	}
 
+	if err := app.Workers.AssessmentRunner.Stop(ctx); err != nil {
+		log.Println("Assessment workers forced to stop: ", err)
+	}
+
 	log.Println("Server exiting")
 }
@@ -9,27 +9,72 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
 
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/assessor"
 	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
 	"github.com/lk153/quizgame-ai-serving/internal/adapters/http"
 	"github.com/lk153/quizgame-ai-serving/internal/adapters/storage"
 	mongoAdapter "github.com/lk153/quizgame-ai-serving/internal/adapters/storage/mongo"
 	"github.com/lk153/quizgame-ai-serving/internal/core/services"
+	assessmentSvc "github.com/lk153/quizgame-ai-serving/internal/core/services/assessment"
 )
 
+type App struct {
+	Handlers Handlers
+	Workers  Workers
+}
+
 type Handlers struct {
 	TaskResultHandler http.TaskResultHandler
+	AssessmentHandler http.AssessmentHandler
+}
+
+type Workers struct {
+	AssessmentRunner *assessmentSvc.Runner
 }
 
 var HandlerSet = wire.NewSet(
+	http.NewAuth,
+	http.NewIdempotency,
 	http.NewTaskResultHandler,
-	wire.Struct(new(Handlers), "TaskResultHandler"))
+	http.NewAssessmentHandler,
+	wire.Struct(new(Handlers), "TaskResultHandler", "AssessmentHandler"))
+
+var WorkerSet = wire.NewSet(
+	provideRunnerOptions,
+	wire.Struct(new(Workers), "AssessmentRunner"))
 
-var SuperSet = wire.NewSet(services.ServiceSet, HandlerSet, storage.StorageSet)
+var SuperSet = wire.NewSet(
+	services.ServiceSet, HandlerSet, WorkerSet, storage.StorageSet, assessor.AssessorSet,
+	provideAssessmentOptions,
+	wire.Struct(new(App), "Handlers", "Workers"))
+
+func provideRunnerOptions(config *config.Assessment) assessmentSvc.RunnerOptions {
+	return assessmentSvc.RunnerOptions{
+		Workers:      config.Workers,
+		JobTimeout:   config.JobTimeout,
+		RetryBackoff: config.RetryBackoff,
+	}
+}
+
+func provideAssessmentOptions(config *config.Assessment) assessmentSvc.Options {
+	return assessmentSvc.Options{
+		CacheTTL: config.CacheTTL,
+	}
+}
 
 func initializeDB(ctx context.Context, config *config.DB) (*mongoAdapter.DB, error) {
 	return mongoAdapter.New(ctx, config)
 }
 
-func initializeHandlers(ctx context.Context, rg *gin.RouterGroup, db *mongoAdapter.DB, rd *config.Redis) Handlers {
+func initializeApp(
+	ctx context.Context,
+	rg *gin.RouterGroup,
+	db *mongoAdapter.DB,
+	rd *config.Redis,
+	au *config.Auth,
+	cp *config.Copilot,
+	oa *config.OpenAI,
+	as *config.Assessment,
+) App {
 	panic(wire.Build(SuperSet))
 }
@@ -10,37 +10,88 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/assessor"
 	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
 	"github.com/lk153/quizgame-ai-serving/internal/adapters/http"
 	"github.com/lk153/quizgame-ai-serving/internal/adapters/storage"
 	"github.com/lk153/quizgame-ai-serving/internal/adapters/storage/mongo"
 	"github.com/lk153/quizgame-ai-serving/internal/adapters/storage/mongo/repository"
+	redis2 "github.com/lk153/quizgame-ai-serving/internal/adapters/storage/redis"
 	"github.com/lk153/quizgame-ai-serving/internal/core/services"
+	"github.com/lk153/quizgame-ai-serving/internal/core/services/assessment"
+	"github.com/lk153/quizgame-ai-serving/internal/core/services/policy"
 	"github.com/lk153/quizgame-ai-serving/internal/core/services/taskResult"
 )
 
 // Injectors from wire.go:
 
-func initializeHandlers(ctx context.Context, rg *gin.RouterGroup, db *mongo.DB, rd *config.Redis) Handlers {
+func initializeApp(ctx context.Context, rg *gin.RouterGroup, db *mongo.DB, rd *config.Redis, au *config.Auth, cp *config.Copilot, oa *config.OpenAI, as *config.Assessment) App {
 	taskResultRepository := repository.NewTaskResultRepository(db)
 	redis := storage.ProvideRedis(ctx, rd)
-	taskResultService := taskresult.NewTaskResultService(taskResultRepository, redis)
-	taskResultHandler := http.NewTaskResultHandler(taskResultService, rg)
+	taskResultPolicy := policy.NewTaskResultPolicy()
+	taskResultService := taskresult.NewTaskResultService(taskResultRepository, redis, taskResultPolicy)
+	iAssessor := assessor.ProvideAssessor(ctx, cp, oa, as)
+	assessmentQueue := redis2.NewAssessmentQueue(redis, as)
+	assessmentProgress := redis2.NewAssessmentProgress(redis)
+	assessmentPolicy := policy.NewAssessmentPolicy(taskResultPolicy)
+	options := provideAssessmentOptions(as)
+	assessmentService := assessment.NewAssessmentService(iAssessor, taskResultService, assessmentQueue, assessmentQueue, assessmentProgress, redis, assessmentPolicy, options)
+	auth := http.NewAuth(au)
+	idempotency := http.NewIdempotency(redis)
+	taskResultHandler := http.NewTaskResultHandler(taskResultService, assessmentService, auth, idempotency, rg)
+	assessmentHandler := http.NewAssessmentHandler(assessmentService, auth, rg)
 	handlers := Handlers{
 		TaskResultHandler: taskResultHandler,
+		AssessmentHandler: assessmentHandler,
 	}
-	return handlers
+	runnerOptions := provideRunnerOptions(as)
+	runner := assessment.NewRunner(assessmentService, assessmentQueue, assessmentQueue, assessmentProgress, runnerOptions)
+	workers := Workers{
+		AssessmentRunner: runner,
+	}
+	app := App{
+		Handlers: handlers,
+		Workers:  workers,
+	}
+	return app
 }
 
 // wire.go:
 
+type App struct {
+	Handlers Handlers
+	Workers  Workers
+}
+
 type Handlers struct {
 	TaskResultHandler http.TaskResultHandler
+	AssessmentHandler http.AssessmentHandler
 }
 
-var HandlerSet = wire.NewSet(http.NewTaskResultHandler, wire.Struct(new(Handlers), "TaskResultHandler"))
+type Workers struct {
+	AssessmentRunner *assessment.Runner
+}
+
+var HandlerSet = wire.NewSet(http.NewAuth, http.NewIdempotency, http.NewTaskResultHandler, http.NewAssessmentHandler, wire.Struct(new(Handlers), "TaskResultHandler", "AssessmentHandler"))
+
+var WorkerSet = wire.NewSet(
+	provideRunnerOptions, wire.Struct(new(Workers), "AssessmentRunner"))
 
-var SuperSet = wire.NewSet(services.ServiceSet, HandlerSet, storage.StorageSet)
+var SuperSet = wire.NewSet(services.ServiceSet, HandlerSet, WorkerSet, storage.StorageSet, assessor.AssessorSet, provideAssessmentOptions, wire.Struct(new(App), "Handlers", "Workers"))
+
+func provideRunnerOptions(config2 *config.Assessment) assessment.RunnerOptions {
+	return assessment.RunnerOptions{
+		Workers:      config2.Workers,
+		JobTimeout:   config2.JobTimeout,
+		RetryBackoff: config2.RetryBackoff,
+	}
+}
+
+func provideAssessmentOptions(config2 *config.Assessment) assessment.Options {
+	return assessment.Options{
+		CacheTTL: config2.CacheTTL,
+	}
+}
 
 func initializeDB(ctx context.Context, config2 *config.DB) (*mongo.DB, error) {
 	return mongo.New(ctx, config2)
@@ -0,0 +1,250 @@
+package agent
+
+import (
+	"context"
+	"errors"
+
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/rubric"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+	errLib "github.com/lk153/quizgame-ai-serving/lib/errors"
+)
+
+var _ ports.IAssessor = &Assessor{}
+
+// UpstreamErrorFunc tags the errors of the service behind the agent with the domain errors which the callers are told
+type UpstreamErrorFunc func(err error) error
+
+/**
+ * Assessor implements port.IAssessor interface
+ * and provides an access to an assessment agent, e.g. the Copilot agent through Direct Line
+ * or a model served through the chat completions protocol
+ */
+type Assessor struct {
+	userID   string
+	agent    *copilotAgent.Agent
+	upstream UpstreamErrorFunc
+}
+
+// New creates an assessor on the agent, the errors of the agent are returned as they are when upstream is nil
+func New(userID string, agent *copilotAgent.Agent, upstream UpstreamErrorFunc) *Assessor {
+	if upstream == nil {
+		upstream = func(err error) error { return err }
+	}
+
+	return &Assessor{
+		userID:   userID,
+		agent:    agent,
+		upstream: upstream,
+	}
+}
+
+// Assess sends the task to the agent and parses its assessment
+func (a *Assessor) Assess(
+	ctx context.Context, input assessmentEntities.InputTask,
+) (*assessmentEntities.Result, error) {
+	var chart *directlinev3.File
+	if input.Chart != nil {
+		chart = &directlinev3.File{
+			Name:        input.Chart.Name,
+			ContentType: input.Chart.ContentType,
+			Data:        input.Chart.Data,
+		}
+	}
+
+	rb, err := input.ResolveRubric()
+	if err != nil {
+		return nil, err
+	}
+
+	promptRubric := toPromptRubric(rb)
+	reply, err := a.agent.DoAssessmentV1(ctx, a.userID, copilotAgent.InputTask{
+		TaskType:        input.TaskType,
+		TaskRequirement: input.TaskRequirement,
+		TaskRelatedDoc:  input.TaskFile,
+		CandidateText:   input.CandidateText,
+		Chart:           chart,
+		PromptVersion:   input.PromptVersion,
+		Rubric:          promptRubric,
+		OnProgress: func(stage string, detail string) {
+			assessmentEntities.ReportProgress(ctx, assessmentEntities.Stage(stage), detail)
+		},
+	})
+	if errors.Is(err, copilotAgent.ErrUnknownPrompt) {
+		errLib.Error.Println(err)
+		return nil, errDomain.ErrUnknownPromptVersion
+	}
+
+	if err != nil {
+		return nil, a.upstream(err)
+	}
+
+	resp, err := copilotAgent.ParseRubricResp(reply.Text, promptRubric)
+	if err != nil {
+		errLib.Error.Println(err)
+		return nil, errDomain.ErrUnparsableAssessment
+	}
+
+	result, err := toResult(resp, rb)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Model = reply.Model
+	result.Usage = assessmentEntities.TokenUsage{
+		PromptTokens:     reply.Usage.PromptTokens,
+		CompletionTokens: reply.Usage.CompletionTokens,
+	}
+	result.PromptVersion = reply.Prompt
+	result.Flags = reply.Flags
+	result.Suspicious = copilotAgent.IsSuspicious(reply.Flags)
+	if result.Suspicious {
+		errLib.Warn.Println("Suspicious candidate text:", reply.Flags)
+	}
+	return result, nil
+}
+
+// PromptVersion returns the version of the prompt which the agent sends for the requested version
+func (a *Assessor) PromptVersion(requested string) string {
+	return a.agent.PromptVersion(requested)
+}
+
+// AssessSpeaking sends the transcript of a Speaking part to the agent and parses its assessment
+func (a *Assessor) AssessSpeaking(
+	ctx context.Context, input assessmentEntities.SpeakingInput,
+) (*assessmentEntities.SpeakingResult, error) {
+	reply, err := a.agent.DoSpeakingAssessmentV1(ctx, a.userID, copilotAgent.SpeakingTask{
+		Part:          input.Part,
+		Prompts:       input.Prompts,
+		Transcript:    input.TranscriptText(),
+		PromptVersion: input.PromptVersion,
+		OnProgress: func(stage string, detail string) {
+			assessmentEntities.ReportProgress(ctx, assessmentEntities.Stage(stage), detail)
+		},
+	})
+	if errors.Is(err, copilotAgent.ErrUnknownPrompt) {
+		errLib.Error.Println(err)
+		return nil, errDomain.ErrUnknownPromptVersion
+	}
+
+	if err != nil {
+		return nil, a.upstream(err)
+	}
+
+	resp, err := copilotAgent.ParseWritingTaskResp(reply.Text)
+	if err != nil {
+		errLib.Error.Println(err)
+		return nil, errDomain.ErrUnparsableAssessment
+	}
+
+	rb, err := rubric.Get(rubric.IELTSSpeaking)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := toSpeakingResult(resp, rb)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Model = reply.Model
+	result.Usage = assessmentEntities.TokenUsage{
+		PromptTokens:     reply.Usage.PromptTokens,
+		CompletionTokens: reply.Usage.CompletionTokens,
+	}
+	result.PromptVersion = reply.Prompt
+	result.Flags = reply.Flags
+	result.Suspicious = copilotAgent.IsSuspicious(reply.Flags)
+	if result.Suspicious {
+		errLib.Warn.Println("Suspicious transcript:", reply.Flags)
+	}
+	return result, nil
+}
+
+// toSpeakingResult converts the parsed reply into a Speaking assessment.
+// The pronunciation criterion is left out when the bot could not give it a band
+func toSpeakingResult(
+	resp *directlinev3.WritingTaskResp, rb *rubric.Rubric,
+) (*assessmentEntities.SpeakingResult, error) {
+	result := &assessmentEntities.SpeakingResult{
+		Pronunciation: assessmentEntities.PronunciationUnknown,
+	}
+
+	var details []directlinev3.Criteria
+	for _, c := range resp.Details {
+		if c.Name != assessmentEntities.CriterionPronunciation {
+			details = append(details, c)
+			continue
+		}
+
+		if _, err := rb.Parse(c.BandScore); err != nil {
+			errLib.Info.Println("Pronunciation is not reported:", c.BandScore)
+			continue
+		}
+
+		details = append(details, c)
+		result.Pronunciation = assessmentEntities.PronunciationReported
+	}
+
+	resp.Details = details
+	parsed, err := toResult(resp, rb)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Result = *parsed
+	return result, nil
+}
+
+// toPromptRubric describes the rubric to the agent
+func toPromptRubric(rb *rubric.Rubric) *copilotAgent.Rubric {
+	criteria := make([]copilotAgent.RubricCriterion, 0, len(rb.Criteria))
+	for _, c := range rb.Criteria {
+		criteria = append(criteria, copilotAgent.RubricCriterion{
+			Title:       c.Title,
+			Description: c.Description,
+		})
+	}
+
+	return &copilotAgent.Rubric{
+		Exam:     rb.Exam,
+		Task:     rb.Task,
+		Title:    rb.Title,
+		Criteria: criteria,
+		Scale:    rb.Describe(),
+	}
+}
+
+// toResult converts the parsed reply into an assessment result with numeric scores on the scale of the rubric
+func toResult(resp *directlinev3.WritingTaskResp, rb *rubric.Rubric) (*assessmentEntities.Result, error) {
+	result := &assessmentEntities.Result{
+		SuggestEssay: resp.SuggestEssay,
+	}
+
+	for _, c := range resp.Details {
+		score, err := rb.Parse(c.BandScore)
+		if err != nil {
+			errLib.Error.Println(err)
+			return nil, errDomain.ErrUnparsableAssessment
+		}
+
+		result.Details = append(result.Details, assessmentEntities.Criterion{
+			Name:         c.Name,
+			BandScore:    score,
+			HowToImprove: c.HowToImprove,
+			Strengths:    c.Strengths,
+		})
+	}
+
+	score, err := rb.ParseOverall(resp.OverallScore)
+	if err != nil {
+		errLib.Error.Println(err)
+		return nil, errDomain.ErrUnparsableAssessment
+	}
+
+	result.OverallScore = score
+	return result, nil
+}
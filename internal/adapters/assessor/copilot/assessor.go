@@ -0,0 +1,81 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/assessor/agent"
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+)
+
+// New creates a Copilot assessor instance.
+// With a secret every assessment talks in a pooled conversation of its own,
+// otherwise the configured conversation is shared and its token is kept alive until the context is done
+func New(ctx context.Context, config *config.Copilot) *agent.Assessor {
+	opts := []directlinev3.Option{
+		directlinev3.WithBaseURL(config.BaseURL),
+		directlinev3.WithSecret(config.Secret),
+		directlinev3.WithUserAgent(config.UserAgent),
+	}
+	if config.Timeout > 0 {
+		opts = append(opts, directlinev3.WithTimeout(config.Timeout))
+	}
+
+	prompts, err := copilotAgent.NewPromptRegistry(config.PromptDir)
+	if err != nil {
+		panic(fmt.Sprintf("Load prompts failed: %s", err.Error()))
+	}
+
+	agentOpts := []copilotAgent.AgentOption{
+		copilotAgent.WithPrompts(prompts),
+		copilotAgent.WithPromptVersion(config.PromptVersion),
+	}
+
+	if config.Secret != "" {
+		pool := copilotAgent.NewConversationPool(directlinev3.New(opts...), copilotAgent.PoolOptions{
+			MaxSize:     config.PoolSize,
+			IdleTimeout: config.PoolIdleTimeout,
+		})
+		go pool.Run(ctx)
+
+		return agent.New(
+			config.UserID,
+			copilotAgent.NewAgent(directlinev3.New(opts...), "", append(agentOpts, copilotAgent.WithConversationPool(pool))...),
+			upstreamError,
+		)
+	}
+
+	if config.Token != "" {
+		tokens := directlinev3.NewTokenManager(
+			directlinev3.New(opts...),
+			directlinev3.WithInitialToken(config.Token, config.TokenExpiresAt),
+		)
+		go tokens.Run(ctx)
+
+		opts = append(opts, directlinev3.WithTokenSource(tokens))
+	}
+
+	return agent.New(
+		config.UserID,
+		copilotAgent.NewAgent(directlinev3.New(opts...), config.ConversationID, agentOpts...),
+		upstreamError,
+	)
+}
+
+// upstreamError tags the failures of Direct Line with the domain error which the callers are told,
+// the cause is kept to be logged
+func upstreamError(err error) error {
+	switch {
+	case errors.Is(err, directlinev3.ErrRateLimited):
+		return fmt.Errorf("%w: %w", errDomain.ErrAssessorRateLimited, err)
+	case errors.As(err, new(*directlinev3.APIError)), errors.Is(err, directlinev3.ErrTokenExpired):
+		// An expired Direct Line token is a failure of the assessor, not of the credentials of the caller
+		return fmt.Errorf("%w: %w", errDomain.ErrAssessorUnavailable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,58 @@
+package assessor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/wire"
+
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/assessor/copilot"
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/assessor/ensemble"
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/assessor/openai"
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+)
+
+// Backends which could assess the tasks, see config.Assessment.Backends
+const (
+	BackendCopilot = "copilot"
+	BackendOpenAI  = "openai"
+)
+
+var AssessorSet = wire.NewSet(
+	ProvideAssessor,
+)
+
+// ProvideAssessor returns the assessor of the configured backend, the Copilot agent when none is configured.
+// It is wrapped in an ensemble when several backends or several runs are configured
+func ProvideAssessor(
+	ctx context.Context, cp *config.Copilot, oa *config.OpenAI, config *config.Assessment,
+) ports.IAssessor {
+	backends := config.Backends
+	if len(backends) == 0 {
+		backends = []string{BackendCopilot}
+	}
+
+	members := make([]ports.IAssessor, 0, len(backends))
+	for _, backend := range backends {
+		switch backend {
+		case BackendCopilot:
+			members = append(members, copilot.New(ctx, cp))
+		case BackendOpenAI:
+			members = append(members, openai.New(oa))
+		default:
+			panic(fmt.Sprintf("Unknown assessment backend: %s", backend))
+		}
+	}
+
+	if len(members) == 1 && config.EnsembleRuns <= 1 {
+		return members[0]
+	}
+
+	return ensemble.New(members, ensemble.Options{
+		Runs:            config.EnsembleRuns,
+		Method:          assessmentEntities.EnsembleMethod(config.EnsembleMethod),
+		SpreadThreshold: config.EnsembleSpreadThreshold,
+	})
+}
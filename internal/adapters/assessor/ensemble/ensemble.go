@@ -0,0 +1,161 @@
+package ensemble
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/rubric"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	errLib "github.com/lk153/quizgame-ai-serving/lib/errors"
+)
+
+var _ ports.IAssessor = &Assessor{}
+
+// Options contains the settings of an ensemble
+type Options struct {
+	// Runs is the number of times each member assesses a task
+	Runs            int
+	Method          assessmentEntities.EnsembleMethod
+	SpreadThreshold float64
+}
+
+/**
+ * Assessor implements port.IAssessor interface
+ * and combines the assessments of several runs of its members
+ */
+type Assessor struct {
+	members []ports.IAssessor
+	opts    Options
+}
+
+// New creates an ensemble which runs every member the given number of times in parallel
+func New(members []ports.IAssessor, opts Options) *Assessor {
+	if len(members) == 0 {
+		panic("Ensemble has no assessor")
+	}
+
+	if opts.Runs <= 0 {
+		opts.Runs = 1
+	}
+
+	if opts.Method == "" {
+		opts.Method = assessmentEntities.EnsembleMedian
+	}
+
+	return &Assessor{
+		members: members,
+		opts:    opts,
+	}
+}
+
+// PromptVersion lists the distinct prompt versions of the members for the requested version
+func (a *Assessor) PromptVersion(requested string) string {
+	var versions []string
+	for _, member := range a.members {
+		if version := member.PromptVersion(requested); !slices.Contains(versions, version) {
+			versions = append(versions, version)
+		}
+	}
+
+	return strings.Join(versions, ",")
+}
+
+// Assess assesses the task with every run and combines the results
+func (a *Assessor) Assess(
+	ctx context.Context, input assessmentEntities.InputTask,
+) (*assessmentEntities.Result, error) {
+	rb, err := input.ResolveRubric()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = assessmentEntities.WithSerialProgress(ctx)
+	results, failed, err := a.run(func(member ports.IAssessor) (*assessmentEntities.Result, error) {
+		return member.Assess(ctx, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assessmentEntities.AggregateResults(results, failed, a.opts.Method, a.opts.SpreadThreshold, rb), nil
+}
+
+// AssessSpeaking assesses the Speaking part with every run and combines the results,
+// the pronunciation is reported when at least half of the runs could judge it
+func (a *Assessor) AssessSpeaking(
+	ctx context.Context, input assessmentEntities.SpeakingInput,
+) (*assessmentEntities.SpeakingResult, error) {
+	rb, err := rubric.Get(rubric.IELTSSpeaking)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = assessmentEntities.WithSerialProgress(ctx)
+	results, failed, err := a.run(func(member ports.IAssessor) (*assessmentEntities.Result, error) {
+		result, err := member.AssessSpeaking(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		return &result.Result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	combined := &assessmentEntities.SpeakingResult{
+		Result:        *assessmentEntities.AggregateResults(results, failed, a.opts.Method, a.opts.SpreadThreshold, rb),
+		Pronunciation: assessmentEntities.PronunciationUnknown,
+	}
+	for _, c := range combined.Details {
+		if c.Name == assessmentEntities.CriterionPronunciation {
+			combined.Pronunciation = assessmentEntities.PronunciationReported
+		}
+	}
+
+	return combined, nil
+}
+
+// run calls every member the configured number of times in parallel,
+// the callers serialise the progress of the runs with WithSerialProgress.
+// The failed runs are counted, the error of the first one is returned when no run succeeds
+func (a *Assessor) run(
+	assess func(member ports.IAssessor) (*assessmentEntities.Result, error),
+) (results []*assessmentEntities.Result, failed int, err error) {
+	total := len(a.members) * a.opts.Runs
+	outcomes := make([]*assessmentEntities.Result, total)
+	errs := make([]error, total)
+
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outcomes[i], errs[i] = assess(a.members[i%len(a.members)])
+		}(i)
+	}
+
+	wg.Wait()
+	for i, result := range outcomes {
+		if errs[i] != nil {
+			errLib.Error.Println("Ensemble run failed:", errs[i])
+			if err == nil {
+				err = errs[i]
+			}
+
+			failed++
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	if len(results) > 0 {
+		err = nil
+	}
+
+	return
+}
@@ -0,0 +1,107 @@
+package ensemble
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/rubric"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+)
+
+var testTask = assessmentEntities.InputTask{TaskType: 2, CandidateText: "essay"}
+
+// stubAssessor reports its progress several times and scores every criterion with the same band
+type stubAssessor struct {
+	ports.IAssessor
+	band float64
+	err  error
+}
+
+func (s *stubAssessor) Assess(ctx context.Context, input assessmentEntities.InputTask) (*assessmentEntities.Result, error) {
+	for _, stage := range []assessmentEntities.Stage{
+		assessmentEntities.StageSentToBot, assessmentEntities.StageWaiting, assessmentEntities.StageParsed,
+	} {
+		assessmentEntities.ReportProgress(ctx, stage, "")
+	}
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	rb := rubric.ForIELTSWritingTask(input.TaskType)
+	result := &assessmentEntities.Result{OverallScore: s.band}
+	for _, c := range rb.Criteria {
+		result.Details = append(result.Details, assessmentEntities.Criterion{Name: c.Name, BandScore: s.band})
+	}
+
+	return result, nil
+}
+
+func (s *stubAssessor) PromptVersion(requested string) string {
+	return "v1"
+}
+
+func TestAssessProgressIsSerialised(t *testing.T) {
+	a := New([]ports.IAssessor{&stubAssessor{band: 6}, &stubAssessor{band: 7}}, Options{Runs: 4})
+
+	// The receiver is not safe for concurrent use, like the one of the runner which updates its job
+	job := assessmentEntities.NewJob(testTask)
+	var stages []assessmentEntities.Stage
+	ctx := assessmentEntities.WithProgress(context.Background(), func(stage assessmentEntities.Stage, detail string) {
+		job.Stage = stage
+		stages = append(stages, job.Stage)
+	})
+
+	result, err := a.Assess(ctx, testTask)
+	if err != nil {
+		t.Fatalf("Assess() error = %v", err)
+	}
+
+	if len(stages) != 8*3 {
+		t.Errorf("progress = %d stages, want 3 per run", len(stages))
+	}
+
+	if result.Ensemble == nil || result.Ensemble.Runs != 8 || result.Ensemble.Failed != 0 {
+		t.Errorf("Assess() ensemble = %+v, want 8 runs", result.Ensemble)
+	}
+}
+
+func TestAssessFailedRuns(t *testing.T) {
+	upstream := errors.New("bot is down")
+	tests := []struct {
+		name       string
+		members    []ports.IAssessor
+		wantErr    error
+		wantFailed int
+	}{
+		{
+			name:       "a failed member is counted",
+			members:    []ports.IAssessor{&stubAssessor{band: 6}, &stubAssessor{err: upstream}},
+			wantFailed: 2,
+		},
+		{
+			name:    "every run fails",
+			members: []ports.IAssessor{&stubAssessor{err: upstream}},
+			wantErr: upstream,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New(tt.members, Options{Runs: 2}).Assess(context.Background(), testTask)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Assess() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if result.Ensemble.Failed != tt.wantFailed || result.OverallScore != 6 {
+				t.Errorf("Assess() overall = %v, ensemble = %+v", result.OverallScore, result.Ensemble)
+			}
+		})
+	}
+}
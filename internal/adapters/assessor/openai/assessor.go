@@ -0,0 +1,62 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/assessor/agent"
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent"
+	openaiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/openai"
+)
+
+// New creates an assessor which sends the prompts to an OpenAI compatible chat completions API
+func New(config *config.OpenAI) *agent.Assessor {
+	opts := []openaiLib.Option{
+		openaiLib.WithBaseURL(config.BaseURL),
+		openaiLib.WithAPIVersion(config.APIVersion),
+		openaiLib.WithModel(config.Model),
+		openaiLib.WithMaxTokens(config.MaxTokens),
+	}
+	if config.APIKey != "" {
+		opts = append(opts, openaiLib.WithAPIKey(config.APIKey))
+	}
+
+	if config.Temperature != nil {
+		opts = append(opts, openaiLib.WithTemperature(*config.Temperature))
+	}
+
+	if config.Timeout > 0 {
+		opts = append(opts, openaiLib.WithTimeout(config.Timeout))
+	}
+
+	prompts, err := copilotAgent.NewPromptRegistry(config.PromptDir)
+	if err != nil {
+		panic(fmt.Sprintf("Load prompts failed: %s", err.Error()))
+	}
+
+	return agent.New(
+		config.UserID,
+		copilotAgent.NewChatAgent(
+			openaiLib.New(opts...),
+			copilotAgent.WithPrompts(prompts),
+			copilotAgent.WithPromptVersion(config.PromptVersion),
+			copilotAgent.WithResponseFormat(config.ResponseFormat),
+		),
+		upstreamError,
+	)
+}
+
+// upstreamError tags the failures of the chat completions API with the domain error which the callers are told,
+// the cause is kept to be logged
+func upstreamError(err error) error {
+	switch {
+	case errors.Is(err, openaiLib.ErrRateLimited):
+		return fmt.Errorf("%w: %w", errDomain.ErrAssessorRateLimited, err)
+	case errors.As(err, new(*openaiLib.APIError)), errors.Is(err, openaiLib.ErrEmptyCompletion):
+		return fmt.Errorf("%w: %w", errDomain.ErrAssessorUnavailable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,200 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	openaiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/openai"
+)
+
+const assessmentReply = `{
+	"details": [
+		{"name": "task-response", "band_score": "7", "how_to_improve": "Develop the examples", "strengths": "Clear position"},
+		{"name": "coherence-and-cohesion", "band_score": "6.5", "how_to_improve": "", "strengths": ""},
+		{"name": "lexical-resource", "band_score": "7", "how_to_improve": "", "strengths": ""},
+		{"name": "grammatical-range-and-accuracy", "band_score": "6.5", "how_to_improve": "", "strengths": ""}
+	],
+	"overall_score": "7",
+	"suggest_essay": "A better essay"
+}`
+
+var testTask = assessmentEntities.InputTask{
+	TaskType:        2,
+	TaskRequirement: "Some people think that children should learn at home. Discuss both views.",
+	CandidateText:   "It is often argued that schools are the best place to learn.",
+}
+
+func testConfig(serverURL string) *config.OpenAI {
+	return &config.OpenAI{
+		UserID:         "quizgame",
+		BaseURL:        serverURL,
+		APIKey:         "test-key",
+		Model:          "gpt-test",
+		ResponseFormat: openaiLib.ResponseFormatJSON,
+		MaxTokens:      512,
+	}
+}
+
+func TestAssess(t *testing.T) {
+	var req struct {
+		Model          string `json:"model"`
+		MaxTokens      int    `json:"max_tokens"`
+		User           string `json:"user"`
+		ResponseFormat struct {
+			Type string `json:"type"`
+		} `json:"response_format"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/chat/completions" {
+			t.Errorf("request = %s %s, want POST /chat/completions", r.Method, r.URL.Path)
+		}
+
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("request body: %v", err)
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"model": "gpt-test-2025",
+			"choices": []map[string]any{
+				{"index": 0, "message": map[string]string{"role": "assistant", "content": assessmentReply}},
+			},
+			"usage": map[string]int{"prompt_tokens": 1200, "completion_tokens": 300, "total_tokens": 1500},
+		})
+	}))
+	defer server.Close()
+
+	result, err := New(testConfig(server.URL)).Assess(context.Background(), testTask)
+	if err != nil {
+		t.Fatalf("Assess() error = %v", err)
+	}
+
+	if req.Model != "gpt-test" || req.MaxTokens != 512 || req.User != "quizgame" || req.ResponseFormat.Type != openaiLib.ResponseFormatJSON {
+		t.Errorf("request = %+v", req)
+	}
+
+	if len(req.Messages) != 1 || req.Messages[0].Role != openaiLib.RoleUser ||
+		!strings.Contains(req.Messages[0].Content, testTask.CandidateText) || !strings.Contains(req.Messages[0].Content, `"overall_score"`) {
+		t.Errorf("messages = %+v, want the json prompt", req.Messages)
+	}
+
+	if result.PromptVersion != "ielts-writing/task2/json-v1" {
+		t.Errorf("Assess() prompt = %q, want the json prompt", result.PromptVersion)
+	}
+
+	if result.Model != "gpt-test-2025" || result.Usage.PromptTokens != 1200 || result.Usage.CompletionTokens != 300 {
+		t.Errorf("Assess() model = %q, usage = %+v", result.Model, result.Usage)
+	}
+
+	if len(result.Details) != 4 || result.OverallScore != 7 || result.SuggestEssay != "A better essay" {
+		t.Errorf("Assess() result = %+v", result)
+	}
+}
+
+func TestAssessErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{"error": {"message": "bad key", "type": "invalid_request_error"}}`, wantErr: errDomain.ErrAssessorUnavailable},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: `{"error": {"message": "slow down", "type": "requests"}}`, wantErr: errDomain.ErrAssessorRateLimited},
+		{name: "server error", status: http.StatusInternalServerError, body: `{"error": {"message": "boom"}}`, wantErr: errDomain.ErrAssessorUnavailable},
+		{name: "no choice", status: http.StatusOK, body: `{"choices": []}`, wantErr: errDomain.ErrAssessorUnavailable},
+		{name: "unparsable reply", status: http.StatusOK, body: `{"choices": [{"message": {"role": "assistant", "content": "I can not help"}}]}`, wantErr: errDomain.ErrUnparsableAssessment},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The retries of the client do not wait
+				w.Header().Set("retry-after-ms", "1")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := New(testConfig(server.URL)).Assess(context.Background(), testTask)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Assess() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssessJSONPrompts(t *testing.T) {
+	toeflReply := `{"details": [
+		{"name": "development", "band_score": "4"},
+		{"name": "organization", "band_score": "4"},
+		{"name": "language-use", "band_score": "3"}
+	], "overall_score": "4"}`
+
+	tests := []struct {
+		name       string
+		task       assessmentEntities.InputTask
+		reply      string
+		wantPrompt string
+		wantErr    error
+	}{
+		{
+			name:       "rubric",
+			task:       assessmentEntities.InputTask{TaskType: 2, CandidateText: "essay", Rubric: "toefl-independent-writing"},
+			reply:      toeflReply,
+			wantPrompt: `"name": "language-use"`,
+		},
+		{
+			name:    "prose prompt",
+			task:    assessmentEntities.InputTask{TaskType: 2, CandidateText: "essay", PromptVersion: "prose-v1"},
+			wantErr: errDomain.ErrUnknownPromptVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req openaiLib.ChatCompletionReq
+				json.NewDecoder(r.Body).Decode(&req)
+				if len(req.Messages) > 0 {
+					prompt = req.Messages[0].Content
+				}
+
+				json.NewEncoder(w).Encode(map[string]any{
+					"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": tt.reply}}},
+				})
+			}))
+			defer server.Close()
+
+			_, err := New(testConfig(server.URL)).Assess(context.Background(), tt.task)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Assess() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil && prompt != "" {
+				t.Errorf("prompt %q is sent, want none", prompt)
+			}
+
+			if !strings.Contains(prompt, tt.wantPrompt) {
+				t.Errorf("prompt = %q, want %q in it", prompt, tt.wantPrompt)
+			}
+		})
+	}
+}
@@ -2,22 +2,33 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 const (
 	CACHE_ON = "1"
+
+	// DefaultJobTimeout and DefaultVisibilityTimeout apply when ASSESSMENT_JOB_TIMEOUT
+	// and ASSESSMENT_VISIBILITY_TIMEOUT are not set
+	DefaultJobTimeout        = 5 * time.Minute
+	DefaultVisibilityTimeout = 10 * time.Minute
 )
 
-// Container contains environment variables for the application, database, cache, token, and http server
+// Container contains environment variables for the application, database, cache, token, http server and AI assessor
 type (
 	Container struct {
-		App   *App
-		Redis *Redis
-		DB    *DB
-		HTTP  *HTTP
+		App        *App
+		Redis      *Redis
+		DB         *DB
+		HTTP       *HTTP
+		Auth       *Auth
+		Copilot    *Copilot
+		OpenAI     *OpenAI
+		Assessment *Assessment
 	}
 	// App contains all the environment variables for the application
 	App struct {
@@ -49,6 +60,69 @@ type (
 		Port           string
 		AllowedOrigins string
 	}
+	// Auth contains all the environment variables for the authentication of the callers,
+	// the HS256 tokens are accepted with JWTSecret and the RS256 tokens with the keys of JWKSFile
+	Auth struct {
+		JWTSecret  string
+		JWKSFile   string
+		Issuer     string
+		Audience   string
+		RolesClaim string
+		// ClassesClaim lists the classes of the caller, it defaults to "classes"
+		ClassesClaim string
+		Leeway       time.Duration
+	}
+	// Copilot contains all the environment variables for the Copilot assessor
+	Copilot struct {
+		UserID         string
+		Secret         string
+		Token          string
+		TokenExpiresAt time.Time
+		ConversationID string
+		BaseURL        string
+		UserAgent      string
+		Timeout        time.Duration
+		// The conversation pool is used when the secret is set
+		PoolSize        int
+		PoolIdleTimeout time.Duration
+		// PromptDir overrides the embedded prompt templates, PromptVersion is the version used by default
+		PromptDir     string
+		PromptVersion string
+	}
+	// OpenAI contains all the environment variables for the assessor of an OpenAI compatible chat completions API
+	OpenAI struct {
+		UserID string
+		// BaseURL defaults to the OpenAI API, APIVersion is only set for Azure OpenAI
+		BaseURL    string
+		APIKey     string
+		APIVersion string
+		Model      string
+		// Temperature is left to the server when it is nil
+		Temperature *float64
+		// ResponseFormat is "text" or "json_object"
+		ResponseFormat string
+		MaxTokens      int
+		Timeout        time.Duration
+		PromptDir      string
+		PromptVersion  string
+	}
+	// Assessment contains all the environment variables for the background assessment workers
+	Assessment struct {
+		Workers           int
+		JobTimeout        time.Duration
+		MaxAttempts       int
+		VisibilityTimeout time.Duration
+		// RetryBackoff is the delay before a failed job is retried, it doubles at every attempt
+		RetryBackoff time.Duration
+		// Every task is assessed EnsembleRuns times and the scores are combined when it is over 1
+		EnsembleRuns            int
+		EnsembleMethod          string
+		EnsembleSpreadThreshold float64
+		// CacheTTL is how long an assessment is reused for the same content, a negative TTL disables the cache
+		CacheTTL time.Duration
+		// Backends lists the assessors, "copilot" and "openai", the tasks are assessed by all of them
+		Backends []string
+	}
 )
 
 // New creates a new container instance
@@ -89,6 +163,86 @@ func New() (*Container, error) {
 		AllowedOrigins: os.Getenv("HTTP_ALLOWED_ORIGINS"),
 	}
 
+	authLeeway, _ := time.ParseDuration(os.Getenv("AUTH_LEEWAY"))
+	auth := &Auth{
+		JWTSecret:    os.Getenv("AUTH_JWT_SECRET"),
+		JWKSFile:     os.Getenv("AUTH_JWKS_FILE"),
+		Issuer:       os.Getenv("AUTH_ISSUER"),
+		Audience:     os.Getenv("AUTH_AUDIENCE"),
+		RolesClaim:   os.Getenv("AUTH_ROLES_CLAIM"),
+		ClassesClaim: os.Getenv("AUTH_CLASSES_CLAIM"),
+		Leeway:       authLeeway,
+	}
+
+	copilotTimeout, _ := time.ParseDuration(os.Getenv("COPILOT_TIMEOUT"))
+	copilotTokenExpiresAt, _ := time.Parse(time.RFC3339, os.Getenv("COPILOT_TOKEN_EXPIRES_AT"))
+	copilotPoolSize, _ := strconv.Atoi(os.Getenv("COPILOT_POOL_SIZE"))
+	copilotPoolIdleTimeout, _ := time.ParseDuration(os.Getenv("COPILOT_POOL_IDLE_TIMEOUT"))
+	copilot := &Copilot{
+		UserID:          os.Getenv("COPILOT_USER_ID"),
+		Secret:          os.Getenv("COPILOT_SECRET"),
+		Token:           os.Getenv("COPILOT_TOKEN"),
+		TokenExpiresAt:  copilotTokenExpiresAt,
+		ConversationID:  os.Getenv("COPILOT_CONVERSATION_ID"),
+		BaseURL:         os.Getenv("COPILOT_BASE_URL"),
+		UserAgent:       os.Getenv("COPILOT_USER_AGENT"),
+		Timeout:         copilotTimeout,
+		PoolSize:        copilotPoolSize,
+		PoolIdleTimeout: copilotPoolIdleTimeout,
+		PromptDir:       os.Getenv("COPILOT_PROMPT_DIR"),
+		PromptVersion:   os.Getenv("COPILOT_PROMPT_VERSION"),
+	}
+
+	var openAITemperature *float64
+	if temperature, err := strconv.ParseFloat(os.Getenv("OPENAI_TEMPERATURE"), 64); err == nil {
+		openAITemperature = &temperature
+	}
+
+	openAIMaxTokens, _ := strconv.Atoi(os.Getenv("OPENAI_MAX_TOKENS"))
+	openAITimeout, _ := time.ParseDuration(os.Getenv("OPENAI_TIMEOUT"))
+	openAI := &OpenAI{
+		UserID:         os.Getenv("OPENAI_USER_ID"),
+		BaseURL:        os.Getenv("OPENAI_BASE_URL"),
+		APIKey:         os.Getenv("OPENAI_API_KEY"),
+		APIVersion:     os.Getenv("OPENAI_API_VERSION"),
+		Model:          os.Getenv("OPENAI_MODEL"),
+		Temperature:    openAITemperature,
+		ResponseFormat: os.Getenv("OPENAI_RESPONSE_FORMAT"),
+		MaxTokens:      openAIMaxTokens,
+		Timeout:        openAITimeout,
+		PromptDir:      os.Getenv("OPENAI_PROMPT_DIR"),
+		PromptVersion:  os.Getenv("OPENAI_PROMPT_VERSION"),
+	}
+
+	workers, _ := strconv.Atoi(os.Getenv("ASSESSMENT_WORKERS"))
+	jobTimeout, _ := time.ParseDuration(os.Getenv("ASSESSMENT_JOB_TIMEOUT"))
+	if jobTimeout <= 0 {
+		jobTimeout = DefaultJobTimeout
+	}
+
+	visibilityTimeout, _ := time.ParseDuration(os.Getenv("ASSESSMENT_VISIBILITY_TIMEOUT"))
+	if visibilityTimeout <= 0 {
+		visibilityTimeout = DefaultVisibilityTimeout
+	}
+
+	maxAttempts, _ := strconv.Atoi(os.Getenv("ASSESSMENT_MAX_ATTEMPTS"))
+	retryBackoff, _ := time.ParseDuration(os.Getenv("ASSESSMENT_RETRY_BACKOFF"))
+	ensembleRuns, _ := strconv.Atoi(os.Getenv("ASSESSMENT_ENSEMBLE_RUNS"))
+	cacheTTL, _ := time.ParseDuration(os.Getenv("ASSESSMENT_CACHE_TTL"))
+	ensembleSpreadThreshold, _ := strconv.ParseFloat(os.Getenv("ASSESSMENT_ENSEMBLE_SPREAD_THRESHOLD"), 64)
+	assessment := &Assessment{
+		Workers:                 workers,
+		JobTimeout:              jobTimeout,
+		MaxAttempts:             maxAttempts,
+		VisibilityTimeout:       visibilityTimeout,
+		RetryBackoff:            retryBackoff,
+		EnsembleRuns:            ensembleRuns,
+		EnsembleMethod:          os.Getenv("ASSESSMENT_ENSEMBLE_METHOD"),
+		EnsembleSpreadThreshold: ensembleSpreadThreshold,
+		CacheTTL:                cacheTTL,
+		Backends:                splitList(os.Getenv("ASSESSMENT_BACKENDS")),
+	}
+
 	isValid, errMsg := app.validate()
 	if !isValid {
 		panic(errMsg)
@@ -101,15 +255,39 @@ func New() (*Container, error) {
 	if !isValid {
 		panic(errMsg)
 	}
+	isValid, errMsg = auth.validate()
+	if !isValid {
+		panic(errMsg)
+	}
+	isValid, errMsg = assessment.validate()
+	if !isValid {
+		panic(errMsg)
+	}
 
 	return &Container{
 		app,
 		redis,
 		db,
 		http,
+		auth,
+		copilot,
+		openAI,
+		assessment,
 	}, nil
 }
 
+// splitList splits a comma separated list, the empty items are dropped
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
 func (a App) validate() (isValid bool, errMessage string) {
 	isValid = true
 	errMessage = "invalid"
@@ -160,6 +338,31 @@ func (db DB) validate() (isValid bool, errMessage string) {
 	return
 }
 
+func (a Auth) validate() (isValid bool, errMessage string) {
+	isValid = true
+	errMessage = "invalid"
+	switch {
+	case strings.EqualFold(strings.TrimSpace(a.JWTSecret), "") && strings.EqualFold(strings.TrimSpace(a.JWKSFile), ""):
+		isValid = false
+		errMessage = "Please provide AUTH_JWT_SECRET or AUTH_JWKS_FILE"
+	}
+
+	return
+}
+
+// validate requires a job to time out before its lease expires, otherwise a slow job is delivered to another worker
+func (as Assessment) validate() (isValid bool, errMessage string) {
+	isValid = true
+	errMessage = "invalid"
+	switch {
+	case as.JobTimeout >= as.VisibilityTimeout:
+		isValid = false
+		errMessage = "Please provide ASSESSMENT_JOB_TIMEOUT shorter than ASSESSMENT_VISIBILITY_TIMEOUT"
+	}
+
+	return
+}
+
 func (rd Redis) validate() (isValid bool, errMessage string) {
 	isValid = true
 	errMessage = "invalid"
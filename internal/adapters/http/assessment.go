@@ -0,0 +1,137 @@
+package http
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	assessmentDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+)
+
+// AssessmentHandler represents the HTTP handler for related assessment job requests
+type AssessmentHandler struct {
+	svc ports.IAssessmentService
+}
+
+// NewAssessmentHandler creates a new AssessmentHandler instance
+func NewAssessmentHandler(svc ports.IAssessmentService, auth *Auth, rg *gin.RouterGroup) AssessmentHandler {
+	assessmentRouteGroup := rg.Group("/assessments", auth.Authenticate)
+	handler := AssessmentHandler{
+		svc,
+	}
+
+	assessmentRouteGroup.GET("/:jobId", handler.GetAssessmentJob)
+	assessmentRouteGroup.GET("/:jobId/stream", handler.StreamAssessmentJob)
+
+	return handler
+}
+
+// assessmentJobResponse represents an assessment job response body
+type assessmentJobResponse struct {
+	ID           string    `json:"id" example:"4bf0b061-3926-425f-af89-7b4edb1db389"`
+	Status       string    `json:"status" example:"queued"`
+	TaskResultID string    `json:"task_result_id,omitempty" example:"35f1b935-58b1-42ed-8eea-10062906b84f"`
+	Error        string    `json:"error,omitempty" example:"internal error"`
+	CreatedAt    time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt    time.Time `json:"updated_at" example:"2024-01-01T00:00:00Z"`
+}
+
+// newAssessmentJobResponse is a helper function to create a response body for handling assessment job data
+func newAssessmentJobResponse(j *assessmentDomain.Job) *assessmentJobResponse {
+	if j == nil {
+		return nil
+	}
+
+	return &assessmentJobResponse{
+		ID:           j.ID,
+		Status:       string(j.Status),
+		TaskResultID: j.TaskResultID,
+		Error:        j.Error,
+		CreatedAt:    j.CreatedAt,
+		UpdatedAt:    j.UpdatedAt,
+	}
+}
+
+// assessmentProgressResponse represents an assessment progress event body
+type assessmentProgressResponse struct {
+	JobID  string    `json:"job_id" example:"4bf0b061-3926-425f-af89-7b4edb1db389"`
+	Status string    `json:"status" example:"running"`
+	Stage  string    `json:"stage,omitempty" example:"sent_to_bot"`
+	Detail string    `json:"detail,omitempty" example:"35f1b935-58b1-42ed-8eea-10062906b84f"`
+	Time   time.Time `json:"time" example:"2024-01-01T00:00:00Z"`
+}
+
+// newAssessmentProgressResponse is a helper function to create an event body for handling assessment progress
+func newAssessmentProgressResponse(e assessmentDomain.ProgressEvent) assessmentProgressResponse {
+	return assessmentProgressResponse{
+		JobID:  e.JobID,
+		Status: string(e.Status),
+		Stage:  string(e.Stage),
+		Detail: e.Detail,
+		Time:   e.Time,
+	}
+}
+
+// getAssessmentJobRequest represents the request body for getting an assessment job
+type getAssessmentJobRequest struct {
+	JobID string `uri:"jobId" binding:"required" example:"4bf0b061-3926-425f-af89-7b4edb1db389"`
+}
+
+func (h AssessmentHandler) GetAssessmentJob(ctx *gin.Context) {
+	var req getAssessmentJobRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validationError(ctx, err)
+		return
+	}
+
+	job, err := h.svc.GetJob(ctx, req.JobID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	rsp := newAssessmentJobResponse(job)
+	handleSuccess(ctx, rsp)
+}
+
+const (
+	progressEvent     = "progress"
+	heartbeatEvent    = "heartbeat"
+	heartbeatInterval = 15 * time.Second
+)
+
+func (h AssessmentHandler) StreamAssessmentJob(ctx *gin.Context) {
+	var req getAssessmentJobRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validationError(ctx, err)
+		return
+	}
+
+	events, err := h.svc.WatchJob(ctx.Request.Context(), req.JobID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+
+			ctx.SSEvent(progressEvent, newAssessmentProgressResponse(event))
+			return true
+		case t := <-heartbeat.C:
+			ctx.SSEvent(heartbeatEvent, t.UTC())
+			return true
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
+	authDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/auth"
+	domainErr "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	errLib "github.com/lk153/quizgame-ai-serving/lib/errors"
+	"github.com/lk153/quizgame-ai-serving/lib/jwt"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	authorizationBearer = "bearer"
+
+	// AuthSubjectKey and AuthRolesKey are the keys of the caller in the Gin context
+	AuthSubjectKey = "auth_subject"
+	AuthRolesKey   = "auth_roles"
+	AuthClassesKey = "auth_classes"
+
+	defaultClassesClaim = "classes"
+)
+
+/**
+ * Auth is a middleware which authenticates the callers
+ * with the Bearer JWT of the Authorization header
+ */
+type Auth struct {
+	verifier     *jwt.Verifier
+	classesClaim string
+}
+
+// NewAuth creates the authentication middleware, it panics when there is no key or the JWKS file can not be read
+func NewAuth(config *config.Auth) *Auth {
+	opts := []jwt.Option{
+		jwt.WithIssuer(config.Issuer),
+		jwt.WithAudience(config.Audience),
+		jwt.WithRolesClaim(config.RolesClaim),
+		jwt.WithLeeway(config.Leeway),
+	}
+
+	if config.JWTSecret != "" {
+		opts = append(opts, jwt.WithHMACSecret([]byte(config.JWTSecret)))
+	}
+
+	if config.JWKSFile != "" {
+		keys, err := jwt.LoadJWKS(config.JWKSFile)
+		if err != nil {
+			panic(fmt.Sprintf("Load JWKS failed: %s", err.Error()))
+		}
+
+		opts = append(opts, jwt.WithRSAKeys(keys))
+	}
+
+	classesClaim := config.ClassesClaim
+	if strings.TrimSpace(classesClaim) == "" {
+		classesClaim = defaultClassesClaim
+	}
+
+	verifier := jwt.New(opts...)
+	if !verifier.HasKeys() {
+		panic("Auth has no key, please provide AUTH_JWT_SECRET or AUTH_JWKS_FILE")
+	}
+
+	return &Auth{verifier, classesClaim}
+}
+
+// Authenticate rejects the requests without a valid Bearer token,
+// the subject, the roles and the classes of the token are put into the Gin context and the request context
+func (a *Auth) Authenticate(ctx *gin.Context) {
+	header := ctx.GetHeader(authorizationHeader)
+	if strings.TrimSpace(header) == "" {
+		abortWithError(ctx, domainErr.ErrEmptyAuthorizationHeader)
+		return
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) != 2 {
+		abortWithError(ctx, domainErr.ErrInvalidAuthorizationHeader)
+		return
+	}
+
+	if !strings.EqualFold(fields[0], authorizationBearer) {
+		abortWithError(ctx, domainErr.ErrInvalidAuthorizationType)
+		return
+	}
+
+	claims, err := a.verifier.Verify(fields[1])
+	if err != nil {
+		errLib.Info.Println("Token is rejected:", err)
+		if errors.Is(err, jwt.ErrExpiredToken) {
+			abortWithError(ctx, domainErr.ErrExpiredToken)
+			return
+		}
+
+		abortWithError(ctx, domainErr.ErrInvalidToken)
+		return
+	}
+
+	if strings.TrimSpace(claims.Subject) == "" {
+		abortWithError(ctx, domainErr.ErrInvalidToken)
+		return
+	}
+
+	principal := &authDomain.Principal{
+		Subject: claims.Subject,
+		Roles:   claims.Roles,
+		Classes: claims.Strings(a.classesClaim),
+	}
+	ctx.Set(AuthSubjectKey, principal.Subject)
+	ctx.Set(AuthRolesKey, principal.Roles)
+	ctx.Set(AuthClassesKey, principal.Classes)
+	ctx.Request = ctx.Request.WithContext(authDomain.NewContext(ctx.Request.Context(), principal))
+	ctx.Next()
+}
+
+// RequireRoles rejects the authenticated callers which have none of the roles
+func (a *Auth) RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !authDomain.FromContext(ctx.Request.Context()).HasRole(roles...) {
+			abortWithError(ctx, domainErr.ErrForbidden)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// authSubject returns the subject of the authenticated caller, empty when there is none
+func authSubject(ctx *gin.Context) string {
+	return ctx.GetString(AuthSubjectKey)
+}
@@ -0,0 +1,213 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	assessmentDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	domainErr "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	cacheLib "github.com/lk153/quizgame-ai-serving/lib/cache"
+	errLib "github.com/lk153/quizgame-ai-serving/lib/errors"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on the responses which are replayed from the store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultIdempotencyTTL is how long the response of an idempotency key is replayed
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// MaxIdempotentBodyBytes bounds the body which is read to fingerprint a request,
+	// it takes the largest request of the handlers, an assessment with its attached chart
+	MaxIdempotentBodyBytes = assessmentDomain.MaxAttachmentBytes + 1<<20
+	// idempotencyLockTTL releases the key of a request which never completes, e.g. when the server stops
+	idempotencyLockTTL     = 10 * time.Minute
+	maxIdempotencyKeyLen   = 255
+	idempotencyCachePrefix = "idempotency"
+)
+
+// idempotencyRecord is stored for an idempotency key, it is in flight until the response is done
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+/**
+ * Idempotency is a middleware which replays the stored response
+ * of a request sent again with the same Idempotency-Key header
+ */
+type Idempotency struct {
+	cache        ports.ICacheRepository
+	ttl          time.Duration
+	maxBodyBytes int64
+}
+
+// NewIdempotency creates the idempotency middleware, the requests without an Idempotency-Key header pass through
+func NewIdempotency(cache ports.ICacheRepository) *Idempotency {
+	return &Idempotency{
+		cache:        cache,
+		ttl:          DefaultIdempotencyTTL,
+		maxBodyBytes: MaxIdempotentBodyBytes,
+	}
+}
+
+// Handle runs the request once per idempotency key.
+// A key reused with another request is rejected with 422, a key whose request is still running with 409.
+// The responses of server errors are not stored so that the request could be retried,
+// a body over the size limit is rejected with 413 before it is read entirely
+func (i *Idempotency) Handle(ctx *gin.Context) {
+	key := strings.TrimSpace(ctx.GetHeader(IdempotencyKeyHeader))
+	if key == "" || i.cache == nil {
+		ctx.Next()
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLen {
+		abortWithError(ctx, domainErr.ErrInvalidIdempotencyKey)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, i.maxBodyBytes))
+	if errors.As(err, new(*http.MaxBytesError)) {
+		abortWithError(ctx, domainErr.ErrRequestTooLarge)
+		return
+	}
+
+	if err != nil {
+		errLib.Error.Println(err)
+		abortWithError(ctx, domainErr.ErrInternal)
+		return
+	}
+
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	// The key outlives the request, it is stored and released even when the client is gone
+	storeCtx := context.WithoutCancel(ctx.Request.Context())
+	// The keys of two callers never collide
+	cacheKey := cacheLib.GenerateCacheKey(idempotencyCachePrefix, authSubject(ctx)+":"+key)
+	record := idempotencyRecord{Fingerprint: fingerprint(ctx, body)}
+	lock, err := cacheLib.Serialize(record)
+	if err != nil {
+		errLib.Error.Println(err)
+		abortWithError(ctx, domainErr.ErrInternal)
+		return
+	}
+
+	acquired, err := i.cache.SetIfAbsent(storeCtx, cacheKey, lock, idempotencyLockTTL)
+	if err != nil {
+		errLib.Error.Println(err)
+		abortWithError(ctx, domainErr.ErrInternal)
+		return
+	}
+
+	if !acquired {
+		i.replay(ctx, cacheKey, record.Fingerprint)
+		return
+	}
+
+	stored := false
+	defer func() {
+		if stored {
+			return
+		}
+
+		if err := i.cache.Delete(storeCtx, cacheKey); err != nil {
+			errLib.Error.Println(err)
+		}
+	}()
+
+	writer := &recordingWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer = writer
+	ctx.Next()
+
+	if writer.Status() >= 500 {
+		return
+	}
+
+	record.Done = true
+	record.StatusCode = writer.Status()
+	record.ContentType = writer.Header().Get("Content-Type")
+	record.Body = writer.body.Bytes()
+	raw, err := cacheLib.Serialize(record)
+	if err != nil {
+		errLib.Error.Println(err)
+		return
+	}
+
+	if err = i.cache.Set(storeCtx, cacheKey, raw, i.ttl); err != nil {
+		errLib.Error.Println(err)
+		return
+	}
+
+	stored = true
+}
+
+// replay writes the stored response of the key when the request is the same and it is done
+func (i *Idempotency) replay(ctx *gin.Context, cacheKey string, fingerprint string) {
+	raw, err := i.cache.Get(ctx, cacheKey)
+	if err != nil {
+		// The key was released in between, the client is asked to retry
+		abortWithError(ctx, domainErr.ErrRequestInProgress)
+		return
+	}
+
+	var record idempotencyRecord
+	if err = cacheLib.Deserialize(raw, &record); err != nil {
+		errLib.Error.Println(err)
+		abortWithError(ctx, domainErr.ErrInternal)
+		return
+	}
+
+	switch {
+	case record.Fingerprint != fingerprint:
+		abortWithError(ctx, domainErr.ErrIdempotencyKeyReused)
+	case !record.Done:
+		abortWithError(ctx, domainErr.ErrRequestInProgress)
+	default:
+		ctx.Header(IdempotentReplayedHeader, "true")
+		ctx.Data(record.StatusCode, record.ContentType, record.Body)
+		ctx.Abort()
+	}
+}
+
+// fingerprint identifies the request of an idempotency key by its method, path and body
+func fingerprint(ctx *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(ctx.Request.Method + " " + ctx.Request.URL.Path + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func abortWithError(ctx *gin.Context, err error) {
+	handleError(ctx, err)
+	ctx.Abort()
+}
+
+// recordingWriter keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,223 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	domainErr "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+)
+
+// fakeCache keeps the values in memory, the ttls are ignored
+type fakeCache struct {
+	ports.ICacheRepository
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{values: map[string][]byte{}}
+}
+
+func (c *fakeCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = value
+	return nil
+}
+
+func (c *fakeCache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+
+	c.values[key] = value
+	return true, nil
+}
+
+func (c *fakeCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.values[key]
+	if !ok {
+		return nil, domainErr.ErrDataNotFound
+	}
+
+	return value, nil
+}
+
+func (c *fakeCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+	return nil
+}
+
+func (c *fakeCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.values)
+}
+
+// idempotentRouter serves the handler behind the idempotency middleware
+func idempotentRouter(i *Idempotency, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/v1/assess", i.Handle, handler)
+
+	return router
+}
+
+func sendIdempotent(router http.Handler, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/assess", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestIdempotencyReplays(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotentRouter(NewIdempotency(newFakeCache()), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusAccepted, gin.H{"call": calls.Add(1)})
+	})
+
+	first := sendIdempotent(router, "key-1", `{"essay": "text"}`)
+	second := sendIdempotent(router, "key-1", `{"essay": "text"}`)
+	if calls.Load() != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls.Load())
+	}
+
+	if first.Header().Get(IdempotentReplayedHeader) != "" || second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("replayed headers = %q, %q, want only the second response replayed",
+			first.Header().Get(IdempotentReplayedHeader), second.Header().Get(IdempotentReplayedHeader))
+	}
+
+	if second.Code != http.StatusAccepted || second.Body.String() != first.Body.String() ||
+		second.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Errorf("replayed response = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+
+	// Another key runs the request again
+	if sendIdempotent(router, "key-2", `{"essay": "text"}`); calls.Load() != 2 {
+		t.Errorf("handler calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestIdempotencyRejectsReusedKey(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotentRouter(NewIdempotency(newFakeCache()), func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	sendIdempotent(router, "key-1", `{"essay": "text"}`)
+	w := sendIdempotent(router, "key-1", `{"essay": "another text"}`)
+	if w.Code != http.StatusUnprocessableEntity || calls.Load() != 1 {
+		t.Errorf("status = %d after %d calls, want %d after 1 call", w.Code, calls.Load(), http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIdempotencyRejectsInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router := idempotentRouter(NewIdempotency(newFakeCache()), func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- sendIdempotent(router, "key-1", `{"essay": "text"}`) }()
+	<-started
+
+	if w := sendIdempotent(router, "key-1", `{"essay": "text"}`); w.Code != http.StatusConflict {
+		t.Errorf("status = %d while in flight, want %d", w.Code, http.StatusConflict)
+	}
+
+	close(release)
+	if w := <-done; w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if w := sendIdempotent(router, "key-1", `{"essay": "text"}`); w.Code != http.StatusOK || w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("status = %d, replayed = %q after the request is done, want it replayed", w.Code, w.Header().Get(IdempotentReplayedHeader))
+	}
+}
+
+func TestIdempotencyRejectsLargeBody(t *testing.T) {
+	var calls atomic.Int32
+	cache := newFakeCache()
+	i := NewIdempotency(cache)
+	i.maxBodyBytes = 16
+	router := idempotentRouter(i, func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	w := sendIdempotent(router, "key-1", strings.Repeat("x", 17))
+	if w.Code != http.StatusRequestEntityTooLarge || calls.Load() != 0 || cache.len() != 0 {
+		t.Errorf("status = %d after %d calls, %d stored keys, want %d and nothing run",
+			w.Code, calls.Load(), cache.len(), http.StatusRequestEntityTooLarge)
+	}
+
+	if w = sendIdempotent(router, "key-1", strings.Repeat("x", 16)); w.Code != http.StatusOK {
+		t.Errorf("status = %d at the limit, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	cache := newFakeCache()
+	router := idempotentRouter(NewIdempotency(cache), func(ctx *gin.Context) {
+		if calls.Add(1) == 1 {
+			handleError(ctx, domainErr.ErrAssessorUnavailable)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	if w := sendIdempotent(router, "key-1", `{"essay": "text"}`); w.Code != http.StatusBadGateway || cache.len() != 0 {
+		t.Fatalf("status = %d, %d stored keys, want %d and the key released", w.Code, cache.len(), http.StatusBadGateway)
+	}
+
+	w := sendIdempotent(router, "key-1", `{"essay": "text"}`)
+	if w.Code != http.StatusOK || w.Header().Get(IdempotentReplayedHeader) != "" || calls.Load() != 2 {
+		t.Errorf("status = %d after %d calls, want the request run again", w.Code, calls.Load())
+	}
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotentRouter(NewIdempotency(newFakeCache()), func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	sendIdempotent(router, "", `{"essay": "text"}`)
+	sendIdempotent(router, "  ", `{"essay": "text"}`)
+	if calls.Load() != 2 {
+		t.Errorf("handler calls = %d, want every request run", calls.Load())
+	}
+
+	if w := sendIdempotent(router, strings.Repeat("k", maxIdempotencyKeyLen+1), `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d for a long key, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -31,6 +32,12 @@ func handleSuccess(ctx *gin.Context, data any) {
 	ctx.JSON(http.StatusOK, rsp)
 }
 
+// handleAccepted sends an accepted response for a request which is processed in background
+func handleAccepted(ctx *gin.Context, data any) {
+	rsp := newResponse(true, "Accepted", data)
+	ctx.JSON(http.StatusAccepted, rsp)
+}
+
 var errHTTPStatuses = map[error]int{
 	domainErr.ErrInternal:                   http.StatusInternalServerError,
 	domainErr.ErrDataNotFound:               http.StatusNotFound,
@@ -44,9 +51,37 @@ var errHTTPStatuses = map[error]int{
 	domainErr.ErrExpiredToken:               http.StatusUnauthorized,
 	domainErr.ErrForbidden:                  http.StatusForbidden,
 	domainErr.ErrNoUpdatedData:              http.StatusBadRequest,
+	domainErr.ErrUnparsableAssessment:       http.StatusBadGateway,
+	domainErr.ErrQueueFull:                  http.StatusServiceUnavailable,
+	domainErr.ErrUnknownPromptVersion:       http.StatusBadRequest,
+	domainErr.ErrUnknownRubric:              http.StatusBadRequest,
+	domainErr.ErrAssessorUnavailable:        http.StatusBadGateway,
+	domainErr.ErrAssessorRateLimited:        http.StatusTooManyRequests,
+	domainErr.ErrAttachmentTooLarge:         http.StatusRequestEntityTooLarge,
+	domainErr.ErrUnsupportedAttachment:      http.StatusUnsupportedMediaType,
+	domainErr.ErrInvalidIdempotencyKey:      http.StatusBadRequest,
+	domainErr.ErrIdempotencyKeyReused:       http.StatusUnprocessableEntity,
+	domainErr.ErrRequestInProgress:          http.StatusConflict,
+	domainErr.ErrRequestTooLarge:            http.StatusRequestEntityTooLarge,
+}
+
+// toDomainError finds the domain error of a wrapped error, the assessor adapters tag the upstream failures with one.
+// Other wrapped internal errors are reduced to ErrInternal so that their details are not exposed
+func toDomainError(err error) error {
+	switch {
+	case errors.Is(err, domainErr.ErrAssessorRateLimited):
+		return domainErr.ErrAssessorRateLimited
+	case errors.Is(err, domainErr.ErrAssessorUnavailable):
+		return domainErr.ErrAssessorUnavailable
+	case errors.Is(err, domainErr.ErrInternal):
+		return domainErr.ErrInternal
+	}
+
+	return err
 }
 
 func handleError(ctx *gin.Context, err error) {
+	err = toDomainError(err)
 	statusCode, ok := errHTTPStatuses[err]
 	if !ok {
 		statusCode = http.StatusInternalServerError
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+)
+
+func TestHandleError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := errors.New("upstream details")
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantMsg    string
+	}{
+		{name: "domain error", err: errDomain.ErrDataNotFound, wantStatus: http.StatusNotFound, wantMsg: errDomain.ErrDataNotFound.Error()},
+		{
+			name:       "rate limited assessor",
+			err:        fmt.Errorf("%w: %w", errDomain.ErrInternal, fmt.Errorf("%w: %w", errDomain.ErrAssessorRateLimited, upstream)),
+			wantStatus: http.StatusTooManyRequests,
+			wantMsg:    errDomain.ErrAssessorRateLimited.Error(),
+		},
+		{
+			name:       "unavailable assessor",
+			err:        fmt.Errorf("%w: %w", errDomain.ErrInternal, fmt.Errorf("%w: %w", errDomain.ErrAssessorUnavailable, upstream)),
+			wantStatus: http.StatusBadGateway,
+			wantMsg:    errDomain.ErrAssessorUnavailable.Error(),
+		},
+		{
+			name:       "internal details are hidden",
+			err:        fmt.Errorf("%w: %w", errDomain.ErrInternal, upstream),
+			wantStatus: http.StatusInternalServerError,
+			wantMsg:    errDomain.ErrInternal.Error(),
+		},
+		{name: "unknown error", err: upstream, wantStatus: http.StatusInternalServerError, wantMsg: upstream.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			handleError(ctx, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if body := w.Body.String(); !strings.Contains(body, tt.wantMsg) || (tt.err != upstream && strings.Contains(body, upstream.Error())) {
+				t.Errorf("body = %s, want the message %q only", body, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -1,45 +1,50 @@
 package http
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
-	"net/http"
-	"os"
-	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	assessmentDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	authDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/auth"
+	domainErr "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
 	taskResultDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
 	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
-	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent"
-	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+	"github.com/lk153/quizgame-ai-serving/lib/essaymetrics"
 )
 
 // TaskResultHandler represents the HTTP handler for related task result requests
 type TaskResultHandler struct {
-	svc ports.ITaskResultService
+	svc       ports.ITaskResultService
+	assessSvc ports.IAssessmentService
 }
 
 // NewTaskResultHandler creates a new TaskResultHandler instance
-func NewTaskResultHandler(svc ports.ITaskResultService, rg *gin.RouterGroup) TaskResultHandler {
-	taskRouteGroup := rg.Group("/task-result")
+func NewTaskResultHandler(
+	svc ports.ITaskResultService,
+	assessSvc ports.IAssessmentService,
+	auth *Auth,
+	idempotency *Idempotency,
+	rg *gin.RouterGroup,
+) TaskResultHandler {
+	taskRouteGroup := rg.Group("/task-result", auth.Authenticate)
 	handler := TaskResultHandler{
 		svc,
+		assessSvc,
 	}
 
-	taskRouteGroup.POST("/", handler.SubmitTaskResult)
+	taskRouteGroup.POST("/", idempotency.Handle, handler.SubmitTaskResult)
 	taskRouteGroup.GET("/", handler.ListTaskResults)
 	taskRouteGroup.GET("/:id", handler.GetTaskResult)
-	taskRouteGroup.PUT("/", handler.UpdateTaskResult)
-	taskRouteGroup.DELETE("/:id", handler.DeleteTaskResult)
-	taskRouteGroup.POST("/assess", handler.AssessIELTS)
-	taskRouteGroup.POST("/upload", handler.Uploadfile)
+	taskRouteGroup.PUT("/:id", handler.UpdateTaskResult)
+	taskRouteGroup.DELETE("/:id", auth.RequireRoles(authDomain.RoleAdmin), handler.DeleteTaskResult)
+	// The chart of a Writing Task 1 is sent as the multipart chart field of /assess, there is no separate upload route
+	taskRouteGroup.POST("/assess", idempotency.Handle, handler.AssessIELTS)
+	taskRouteGroup.POST("/assess-speaking", handler.AssessIELTSSpeaking)
+	taskRouteGroup.POST("/metrics", handler.MeasureIELTS)
 
 	return handler
 }
@@ -53,22 +58,97 @@ type submitRequest struct {
 
 // taskResultResponse represents a task result response body
 type taskResultResponse struct {
-	ID      string  `json:"id" example:"aaa-bbb-ccc-ddd"`
-	Name    string  `json:"name" example:"John Doe"`
-	Score   float64 `json:"score" example:"6.5"`
-	Comment string  `json:"comment" example:"This is a comment for submitted task"`
+	ID               string                   `json:"id" example:"aaa-bbb-ccc-ddd"`
+	OwnerID          string                   `json:"owner_id,omitempty" example:"student-42"`
+	ClassIDs         []string                 `json:"class_ids,omitempty" example:"ielts-7a"`
+	Name             string                   `json:"name" example:"John Doe"`
+	Exam             string                   `json:"exam,omitempty" example:"ielts-writing"`
+	Rubric           string                   `json:"rubric,omitempty" example:"ielts-writing-task2"`
+	Score            float64                  `json:"score" example:"6.5"`
+	Level            string                   `json:"level,omitempty" example:"B2"`
+	ModelScore       float64                  `json:"model_score,omitempty" example:"7"`
+	ScoreMismatch    bool                     `json:"score_mismatch" example:"true"`
+	Comment          string                   `json:"comment" example:"This is a comment for submitted task"`
+	TaskType         uint8                    `json:"task_type,omitempty" example:"2"`
+	TaskRequirement  string                   `json:"task_requirement,omitempty" example:"This is a writing task"`
+	CandidateText    string                   `json:"candidate_text,omitempty" example:"This is a candidate text"`
+	Criteria         []criterionScoreResponse `json:"criteria,omitempty"`
+	SuggestEssay     string                   `json:"suggest_essay,omitempty" example:"This is a suggested essay"`
+	Model            string                   `json:"model,omitempty" example:"copilot-directline"`
+	PromptVersion    string                   `json:"prompt_version,omitempty" example:"ielts-writing/task2/prose-v1"`
+	Flags            []string                 `json:"flags,omitempty" example:"instruction-override"`
+	Suspicious       bool                     `json:"suspicious" example:"false"`
+	Metrics          *essaymetrics.Metrics    `json:"metrics,omitempty"`
+	Pronunciation    string                   `json:"pronunciation,omitempty" example:"unknown"`
+	Runs             int                      `json:"runs,omitempty" example:"3"`
+	Spread           float64                  `json:"spread" example:"0.5"`
+	NeedsReview      bool                     `json:"needs_review" example:"false"`
+	CacheHit         bool                     `json:"cache_hit" example:"false"`
+	PromptTokens     int                      `json:"prompt_tokens,omitempty" example:"1200"`
+	CompletionTokens int                      `json:"completion_tokens,omitempty" example:"800"`
+	CreatedAt        time.Time                `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt        time.Time                `json:"updated_at" example:"2024-01-01T00:00:00Z"`
 }
 
-// newUserResponse is a helper function to create a response body for handling user data
+// criterionScoreResponse represents the band score of an assessed criterion
+type criterionScoreResponse struct {
+	Name         string  `json:"name" example:"task-response"`
+	BandScore    float64 `json:"band_score" example:"6.5"`
+	HowToImprove string  `json:"how_to_improve" example:"Develop the main ideas further"`
+	Strengths    string  `json:"strengths" example:"Clear position throughout"`
+	Penalty      float64 `json:"penalty,omitempty" example:"0.5"`
+	Spread       float64 `json:"spread,omitempty" example:"1"`
+}
+
+// newTaskResultResponse is a helper function to create a response body for handling task result data
 func newTaskResultResponse(t *taskResultDomain.TaskResultEntity) *taskResultResponse {
 	if t == nil {
 		return nil
 	}
 
+	var criteria []criterionScoreResponse
+	for _, c := range t.Criteria {
+		criteria = append(criteria, criterionScoreResponse{
+			Name:         c.Name,
+			BandScore:    c.BandScore,
+			HowToImprove: c.HowToImprove,
+			Strengths:    c.Strengths,
+			Penalty:      c.Penalty,
+			Spread:       c.Spread,
+		})
+	}
+
 	return &taskResultResponse{
-		ID:    t.ID,
-		Name:  t.Name,
-		Score: float64(t.Score),
+		ID:               t.ID,
+		OwnerID:          t.OwnerID,
+		ClassIDs:         t.ClassIDs,
+		Name:             t.Name,
+		Exam:             t.Exam,
+		Rubric:           t.Rubric,
+		Score:            float64(t.Score),
+		Level:            t.Level,
+		ModelScore:       t.ModelScore,
+		ScoreMismatch:    t.ScoreMismatch,
+		Comment:          t.Comment,
+		TaskType:         t.TaskType,
+		TaskRequirement:  t.TaskRequirement,
+		CandidateText:    t.CandidateText,
+		Criteria:         criteria,
+		SuggestEssay:     t.SuggestEssay,
+		Model:            t.Model,
+		PromptVersion:    t.PromptVersion,
+		Flags:            t.Flags,
+		Suspicious:       t.Suspicious,
+		Metrics:          t.Metrics,
+		Pronunciation:    t.Pronunciation,
+		Runs:             t.Runs,
+		Spread:           t.Spread,
+		NeedsReview:      t.NeedsReview,
+		CacheHit:         t.CacheHit,
+		PromptTokens:     t.PromptTokens,
+		CompletionTokens: t.CompletionTokens,
+		CreatedAt:        t.CreatedAt,
+		UpdatedAt:        t.UpdatedAt,
 	}
 }
 
@@ -170,13 +250,13 @@ func (h TaskResultHandler) UpdateTaskResult(ctx *gin.Context) {
 		Comment: req.Comment,
 	}
 
-	_, err := h.svc.UpdateTaskResult(ctx, &task)
+	updated, err := h.svc.UpdateTaskResult(ctx, &task)
 	if err != nil {
 		handleError(ctx, err)
 		return
 	}
 
-	rsp := newTaskResultResponse(&task)
+	rsp := newTaskResultResponse(updated)
 	handleSuccess(ctx, rsp)
 }
 
@@ -201,108 +281,161 @@ func (h TaskResultHandler) DeleteTaskResult(ctx *gin.Context) {
 	handleSuccess(ctx, nil)
 }
 
-// assessRequest represents the request body for IELTS Writing Task assessment
+// assessRequest represents the request body for Writing Task assessment,
+// the IELTS rubric of the task type applies unless another rubric is requested.
+// It is sent as json or as a multipart form when the chart image of a Writing Task 1 is attached
 type assessRequest struct {
-	TaskType        uint8  `json:"task_type" binding:"required" example:"This is a writing type"`
-	TaskRequirement string `json:"task_requirement" binding:"required" example:"This is a writing task"`
-	TaskFile        string `json:"task_file"`
-	CandidateText   string `json:"candidate_text" binding:"required" example:"This is a candidate text"`
+	TaskType        uint8                 `json:"task_type" form:"task_type" binding:"required" example:"1"`
+	TaskRequirement string                `json:"task_requirement" form:"task_requirement" binding:"required" example:"This is a writing task"`
+	TaskFile        string                `json:"task_file" form:"task_file"`
+	CandidateText   string                `json:"candidate_text" form:"candidate_text" binding:"required" example:"This is a candidate text"`
+	Async           bool                  `json:"async" form:"async" example:"true"`
+	PromptVersion   string                `json:"prompt_version" form:"prompt_version" example:"prose-v1"`
+	Rubric          string                `json:"rubric" form:"rubric" example:"toefl-independent-writing"`
+	ForceRefresh    bool                  `json:"force_refresh" form:"force_refresh" example:"false"`
+	Chart           *multipart.FileHeader `json:"-" form:"chart" swaggerignore:"true"`
 }
 
 func (h TaskResultHandler) AssessIELTS(ctx *gin.Context) {
 	var req assessRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	if err := ctx.ShouldBind(&req); err != nil {
 		validationError(ctx, err)
 		return
 	}
 
-	// result, err := copilotAgent.DoAssessment(ctx, 123, copilotAgent.InputTask{
-	// 	TaskType:        req.TaskType,
-	// 	TaskRequirement: req.TaskRequirement,
-	// 	CandidateText:   req.CandidateText,
-	// })
-	result, err := copilotAgent.DoAssessmentV1(ctx, os.Getenv("COPILOT_USER_ID"), copilotAgent.InputTask{
+	input := assessmentDomain.InputTask{
 		TaskType:        req.TaskType,
 		TaskRequirement: req.TaskRequirement,
+		TaskFile:        req.TaskFile,
 		CandidateText:   req.CandidateText,
-	})
-	if err != nil {
-		handleError(ctx, err)
+		PromptVersion:   req.PromptVersion,
+		Rubric:          req.Rubric,
+		ForceRefresh:    req.ForceRefresh,
+	}
+	if req.Chart != nil {
+		chart, err := readChart(req.Chart)
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+
+		input.Chart = chart
+	}
+	if req.Async {
+		job, err := h.assessSvc.EnqueueTask(ctx, input)
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+
+		rsp := newAssessmentJobResponse(job)
+		handleAccepted(ctx, rsp)
 		return
 	}
 
-	handleSuccess(ctx, result)
-}
-
-func (h TaskResultHandler) Uploadfile(ctx *gin.Context) {
-	// single file
-	uploadedFile, _ := ctx.FormFile("file")
-	log.Println(uploadedFile.Filename)
-
-	tempFile, err := os.CreateTemp("tmp", "prefix")
+	taskResult, err := h.assessSvc.AssessTask(ctx, input)
 	if err != nil {
-		err = errors.New(fmt.Sprintf("create temp file err: %s", err.Error()))
 		handleError(ctx, err)
 		return
 	}
 
-	defer os.Remove(tempFile.Name())
-	ext := filepath.Ext(uploadedFile.Filename)
+	rsp := newTaskResultResponse(taskResult)
+	handleSuccess(ctx, rsp)
+}
 
-	// Upload the file to specific dst.
-	filename := filepath.Base(tempFile.Name())
-	filename = filename + ext
-	defer os.Remove(filename)
+// assessSpeakingRequest represents the request body for IELTS Speaking assessment,
+// the answers of the candidate are sent as a transcript or as timed segments
+type assessSpeakingRequest struct {
+	Part          uint8                    `json:"part" binding:"required,oneof=1 2 3" example:"2"`
+	Prompts       []string                 `json:"prompts" binding:"required,min=1,dive,required" example:"Describe a place you like to visit"`
+	Transcript    string                   `json:"transcript" binding:"required_without=Segments" example:"Well, the place I would like to talk about is..."`
+	Segments      []speakingSegmentRequest `json:"segments" binding:"required_without=Transcript,dive"`
+	PromptVersion string                   `json:"prompt_version" example:"prose-v1"`
+}
 
-	if err := ctx.SaveUploadedFile(uploadedFile, filename); err != nil {
-		err = errors.New(fmt.Sprintf("Upload file err: %s", err.Error()))
-		handleError(ctx, err)
+// speakingSegmentRequest represents a timed turn of a Speaking transcript, the times are in seconds
+type speakingSegmentRequest struct {
+	Start   float64 `json:"start" binding:"min=0" example:"0"`
+	End     float64 `json:"end" binding:"gtefield=Start" example:"12.5"`
+	Speaker string  `json:"speaker" example:"candidate"`
+	Text    string  `json:"text" binding:"required" example:"Well, the place I would like to talk about is..."`
+}
+
+func (h TaskResultHandler) AssessIELTSSpeaking(ctx *gin.Context) {
+	var req assessSpeakingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validationError(ctx, err)
 		return
 	}
 
-	buf := &bytes.Buffer{}
-	mpw := multipart.NewWriter(buf)
-	fWriter, err := mpw.CreateFormFile("file", filename)
-	if err != nil {
-		handleError(ctx, err)
-		return
+	input := assessmentDomain.SpeakingInput{
+		Part:          req.Part,
+		Prompts:       req.Prompts,
+		Transcript:    req.Transcript,
+		PromptVersion: req.PromptVersion,
+	}
+	for _, s := range req.Segments {
+		input.Segments = append(input.Segments, assessmentDomain.SpeakingSegment{
+			Start:   s.Start,
+			End:     s.End,
+			Speaker: s.Speaker,
+			Text:    s.Text,
+		})
 	}
 
-	_, err = io.Copy(fWriter, tempFile)
+	taskResult, err := h.assessSvc.AssessSpeaking(ctx, input)
 	if err != nil {
 		handleError(ctx, err)
 		return
 	}
 
-	// Close the multipart writer before creating the request
-	err = mpw.Close()
-	if err != nil {
-		handleError(ctx, err)
+	rsp := newTaskResultResponse(taskResult)
+	handleSuccess(ctx, rsp)
+}
+
+// measureRequest represents the request body for computing the metrics of a Writing Task
+type measureRequest struct {
+	TaskType      uint8  `json:"task_type" binding:"required" example:"2"`
+	CandidateText string `json:"candidate_text" binding:"required" example:"This is a candidate text"`
+	Rubric        string `json:"rubric" example:"ielts-writing-task2"`
+}
+
+func (h TaskResultHandler) MeasureIELTS(ctx *gin.Context) {
+	var req measureRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validationError(ctx, err)
 		return
 	}
 
-	url := fmt.Sprintf("https://directline.botframework.com/v3/directline/conversations/%s/upload?userId=%s", os.Getenv("COPILOT_CONVERSATION_ID"), os.Getenv("COPILOT_USER_ID"))
-	req, err := http.NewRequest("POST", url, buf)
+	rsp, err := h.assessSvc.MeasureTask(ctx, assessmentDomain.InputTask{
+		TaskType:      req.TaskType,
+		CandidateText: req.CandidateText,
+		Rubric:        req.Rubric,
+	})
 	if err != nil {
 		handleError(ctx, err)
 		return
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", os.Getenv("COPILOT_TOKEN")))
-	req.Header.Add("Content-Type", mpw.FormDataContentType())
-	client := &http.Client{Transport: http.DefaultTransport}
-	resp, err := client.Do(req)
+	handleSuccess(ctx, rsp)
+}
+
+// readChart reads the uploaded chart image, files over the size limit are rejected before they are read
+func readChart(file *multipart.FileHeader) (*assessmentDomain.Attachment, error) {
+	if file.Size > assessmentDomain.MaxAttachmentBytes {
+		return nil, domainErr.ErrAttachmentTooLarge
+	}
+
+	f, err := file.Open()
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	defer resp.Body.Close()
-	var data directlinev3.UploadSendFileResp
-	err = json.NewDecoder(resp.Body).Decode(&data)
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, assessmentDomain.MaxAttachmentBytes+1))
 	if err != nil {
-		handleError(ctx, err)
-		return
+		return nil, err
 	}
 
-	handleSuccess(ctx, data)
+	return assessmentDomain.NewChartAttachment(file.Filename, data)
 }
@@ -0,0 +1,193 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	assessmentDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	domainErr "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/rubric"
+	taskResultDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	assessmentSvc "github.com/lk153/quizgame-ai-serving/internal/core/services/assessment"
+)
+
+// stubAssessor scores every criterion of the rubric with the same band, or fails with err.
+// It keeps the last task it assessed
+type stubAssessor struct {
+	ports.IAssessor
+	band  float64
+	err   error
+	input assessmentDomain.InputTask
+}
+
+func (s *stubAssessor) Assess(ctx context.Context, input assessmentDomain.InputTask) (*assessmentDomain.Result, error) {
+	s.input = input
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	rb, err := input.ResolveRubric()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &assessmentDomain.Result{OverallScore: s.band, Model: "stub-model"}
+	for _, c := range rb.Criteria {
+		result.Details = append(result.Details, assessmentDomain.Criterion{Name: c.Name, BandScore: s.band})
+	}
+
+	return result, nil
+}
+
+func (s *stubAssessor) PromptVersion(requested string) string {
+	return "prose-v1"
+}
+
+// stubTaskResults stores the submitted task result under a fixed id
+type stubTaskResults struct {
+	ports.ITaskResultService
+}
+
+func (s *stubTaskResults) SubmitTask(
+	ctx context.Context, taskResult *taskResultDomain.TaskResultEntity,
+) (*taskResultDomain.TaskResultEntity, error) {
+	taskResult.ID = "result-1"
+	return taskResult, nil
+}
+
+// allowAll lets every caller have a task assessed
+type allowAll struct {
+	ports.IAssessmentPolicy
+}
+
+func (allowAll) CanAssess(ctx context.Context) error {
+	return nil
+}
+
+func TestAssessIELTS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		assessor   *stubAssessor
+		wantStatus int
+		wantMsg    string
+	}{
+		{name: "success", assessor: &stubAssessor{band: 6.5}, wantStatus: http.StatusOK},
+		{
+			name:       "unparsable assessment",
+			assessor:   &stubAssessor{err: domainErr.ErrUnparsableAssessment},
+			wantStatus: http.StatusBadGateway,
+			wantMsg:    domainErr.ErrUnparsableAssessment.Error(),
+		},
+		{
+			name:       "rate limited assessor",
+			assessor:   &stubAssessor{err: fmt.Errorf("%w: 429 Too Many Requests", domainErr.ErrAssessorRateLimited)},
+			wantStatus: http.StatusTooManyRequests,
+			wantMsg:    domainErr.ErrAssessorRateLimited.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := assessmentSvc.NewAssessmentService(
+				tt.assessor, &stubTaskResults{}, nil, nil, nil, nil, allowAll{}, assessmentSvc.Options{},
+			)
+			handler := TaskResultHandler{assessSvc: svc}
+			router := gin.New()
+			router.POST("/v1/task-result/assess", handler.AssessIELTS)
+
+			// The essay is long enough not to be penalised
+			essay := strings.Repeat("Some people think that children learn best at home with their parents. ", 25)
+			body := fmt.Sprintf(`{"task_type": 2, "task_requirement": "Discuss both views.", "candidate_text": %q}`, essay)
+			req := httptest.NewRequest(http.MethodPost, "/v1/task-result/assess", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			if tt.wantMsg != "" {
+				var rsp errorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil || len(rsp.Messages) != 1 || rsp.Messages[0] != tt.wantMsg {
+					t.Errorf("body = %s, want the message %q", w.Body, tt.wantMsg)
+				}
+
+				return
+			}
+
+			var rsp struct {
+				Data taskResultResponse `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+				t.Fatal(err)
+			}
+
+			if rsp.Data.ID != "result-1" || rsp.Data.Score != 6.5 || rsp.Data.Rubric != rubric.IELTSWritingTask2 ||
+				len(rsp.Data.Criteria) != 4 || rsp.Data.Criteria[0].Penalty != 0 || rsp.Data.Model != "stub-model" {
+				t.Errorf("data = %+v", rsp.Data)
+			}
+		})
+	}
+}
+
+func TestAssessIELTSValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := TaskResultHandler{}
+	router := gin.New()
+	router.POST("/v1/task-result/assess", handler.AssessIELTS)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/task-result/assess", strings.NewReader(`{"task_type": 2}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestAssessIELTSChart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	assessor := &stubAssessor{band: 6}
+	svc := assessmentSvc.NewAssessmentService(assessor, &stubTaskResults{}, nil, nil, nil, nil, allowAll{}, assessmentSvc.Options{})
+	handler := TaskResultHandler{assessSvc: svc}
+	router := gin.New()
+	router.POST("/v1/task-result/assess", handler.AssessIELTS)
+
+	// The chart of a Writing Task 1 is sent along with the task as a multipart form
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("task_type", "1")
+	form.WriteField("task_requirement", "The chart shows the number of visitors to three museums.")
+	form.WriteField("candidate_text", strings.Repeat("The number of visitors to the museums rose steadily over the period. ", 15))
+	part, _ := form.CreateFormFile("chart", "chart.png")
+	part.Write(png)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/task-result/assess", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	chart := assessor.input.Chart
+	if chart == nil || chart.Name != "chart.png" || chart.ContentType != "image/png" || !bytes.Equal(chart.Data, png) {
+		t.Errorf("chart = %+v, want the uploaded image", chart)
+	}
+}
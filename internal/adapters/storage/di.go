@@ -27,4 +27,10 @@ var StorageSet = wire.NewSet(
 
 	ProvideRedis,
 	wire.Bind(new(ports.ICacheRepository), new(*redis.Redis)),
+
+	redis.NewAssessmentQueue,
+	wire.Bind(new(ports.IAssessmentQueue), new(*redis.AssessmentQueue)),
+	wire.Bind(new(ports.IAssessmentJobRepository), new(*redis.AssessmentQueue)),
+	redis.NewAssessmentProgress,
+	wire.Bind(new(ports.IAssessmentProgress), new(*redis.AssessmentProgress)),
 )
@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
+	assessmentDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	errLib "github.com/lk153/quizgame-ai-serving/lib/errors"
+)
+
+const (
+	defaultQueueSize   = 100
+	defaultMaxAttempts = 3
+)
+
+var (
+	_ ports.IAssessmentQueue         = &AssessmentQueue{}
+	_ ports.IAssessmentJobRepository = &AssessmentJobRepository{}
+	_ ports.IAssessmentProgress      = &AssessmentProgress{}
+)
+
+/**
+ * AssessmentQueue implements port.IAssessmentQueue interface
+ * and keeps the queued jobs in the process memory
+ */
+type AssessmentQueue struct {
+	maxAttempts int
+	jobs        chan *assessmentDomain.Job
+
+	mu          sync.Mutex
+	inflight    map[string]*assessmentDomain.Job
+	deadLetters []assessmentDomain.Job
+}
+
+// NewAssessmentQueue creates an in-memory assessment queue instance
+func NewAssessmentQueue(config *config.Assessment) *AssessmentQueue {
+	maxAttempts := config.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	return &AssessmentQueue{
+		maxAttempts: maxAttempts,
+		jobs:        make(chan *assessmentDomain.Job, defaultQueueSize),
+		inflight:    map[string]*assessmentDomain.Job{},
+	}
+}
+
+// Enqueue pushes a job to the queue, it fails when the queue is full
+func (q *AssessmentQueue) Enqueue(ctx context.Context, job *assessmentDomain.Job) error {
+	select {
+	case q.jobs <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return errDomain.ErrQueueFull
+	}
+}
+
+// Dequeue claims a job from the queue, a done context claims nothing even when a job is ready
+func (q *AssessmentQueue) Dequeue(ctx context.Context) (*assessmentDomain.Job, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case job := <-q.jobs:
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		job.Attempts++
+		q.inflight[job.ID] = job
+		return job, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Ack removes a processed job from the queue
+func (q *AssessmentQueue) Ack(ctx context.Context, job *assessmentDomain.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.inflight, job.ID)
+	return nil
+}
+
+// Nack releases a failed job back to the queue after the delay or moves it to the dead-letter list
+func (q *AssessmentQueue) Nack(ctx context.Context, job *assessmentDomain.Job, delay time.Duration) (bool, error) {
+	q.mu.Lock()
+	claimed, ok := q.inflight[job.ID]
+	delete(q.inflight, job.ID)
+	if ok && claimed.Attempts >= q.maxAttempts {
+		q.deadLetters = append(q.deadLetters, *claimed)
+		q.mu.Unlock()
+		return true, nil
+	}
+	q.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	if delay <= 0 {
+		return false, q.Enqueue(ctx, claimed)
+	}
+
+	time.AfterFunc(delay, func() {
+		if err := q.Enqueue(context.Background(), claimed); err != nil {
+			errLib.Error.Println(err)
+		}
+	})
+
+	return false, nil
+}
+
+// DeadLetter moves a claimed job to the dead-letter list
+func (q *AssessmentQueue) DeadLetter(ctx context.Context, job *assessmentDomain.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if claimed, ok := q.inflight[job.ID]; ok {
+		delete(q.inflight, job.ID)
+		q.deadLetters = append(q.deadLetters, *claimed)
+	}
+
+	return nil
+}
+
+// DeadLetters returns the jobs which reach the maximum attempts
+func (q *AssessmentQueue) DeadLetters() []assessmentDomain.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]assessmentDomain.Job(nil), q.deadLetters...)
+}
+
+/**
+ * AssessmentJobRepository implements port.IAssessmentJobRepository interface
+ * and keeps the job statuses in the process memory
+ */
+type AssessmentJobRepository struct {
+	mu   sync.RWMutex
+	jobs map[string]assessmentDomain.Job
+}
+
+// NewAssessmentJobRepository creates an in-memory assessment job repository instance
+func NewAssessmentJobRepository() *AssessmentJobRepository {
+	return &AssessmentJobRepository{
+		jobs: map[string]assessmentDomain.Job{},
+	}
+}
+
+// Save inserts or replaces an assessment job
+func (r *AssessmentJobRepository) Save(ctx context.Context, job *assessmentDomain.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+// GetByID selects an assessment job by id
+func (r *AssessmentJobRepository) GetByID(ctx context.Context, id string) (*assessmentDomain.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, errDomain.ErrDataNotFound
+	}
+
+	return &job, nil
+}
+
+/**
+ * AssessmentProgress implements port.IAssessmentProgress interface
+ * and broadcasts the job progress to the subscribers of the process
+ */
+type AssessmentProgress struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan assessmentDomain.ProgressEvent]struct{}
+}
+
+// NewAssessmentProgress creates an in-memory assessment progress instance
+func NewAssessmentProgress() *AssessmentProgress {
+	return &AssessmentProgress{
+		subscribers: map[string]map[chan assessmentDomain.ProgressEvent]struct{}{},
+	}
+}
+
+// Publish sends a progress event to the subscribers of its job, slow subscribers miss the event
+func (p *AssessmentProgress) Publish(ctx context.Context, event assessmentDomain.ProgressEvent) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for ch := range p.subscribers[event.JobID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Subscribe listens to the progress events of a job until the context is cancelled
+func (p *AssessmentProgress) Subscribe(
+	ctx context.Context, jobID string,
+) (<-chan assessmentDomain.ProgressEvent, error) {
+	ch := make(chan assessmentDomain.ProgressEvent, defaultQueueSize)
+
+	p.mu.Lock()
+	if p.subscribers[jobID] == nil {
+		p.subscribers[jobID] = map[chan assessmentDomain.ProgressEvent]struct{}{}
+	}
+	p.subscribers[jobID][ch] = struct{}{}
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		delete(p.subscribers[jobID], ch)
+		if len(p.subscribers[jobID]) == 0 {
+			delete(p.subscribers, jobID)
+		}
+		close(ch)
+	}()
+
+	return ch, nil
+}
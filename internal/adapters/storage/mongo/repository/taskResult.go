@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
 	mongoAdapter "github.com/lk153/quizgame-ai-serving/internal/adapters/storage/mongo"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
 	taskResultDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
 	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
 )
@@ -71,12 +72,25 @@ func (t *TaskResultRepository) GetByID(
 	return &taskResult, nil
 }
 
-// List lists all task results from the database
+// List lists the task results matching the filter from the database
 func (t *TaskResultRepository) List(
-	ctx context.Context, skip, limit uint64,
+	ctx context.Context, listFilter taskResultDomain.ListFilter, skip, limit uint64,
 ) ([]taskResultDomain.TaskResultEntity, error) {
 	var tasks []taskResultDomain.TaskResultEntity
 	filter := bson.D{}
+	if !listFilter.IsEmpty() {
+		var or bson.A
+		if listFilter.OwnerID != "" {
+			or = append(or, bson.D{{Key: "owner_id", Value: listFilter.OwnerID}})
+		}
+
+		if len(listFilter.ClassIDs) > 0 {
+			or = append(or, bson.D{{Key: "class_ids", Value: bson.D{{Key: "$in", Value: listFilter.ClassIDs}}}})
+		}
+
+		filter = bson.D{{Key: "$or", Value: or}}
+	}
+
 	opts := options.Find().
 		SetSort(bson.D{{Key: "id", Value: 1}}).
 		SetLimit(int64(limit)).SetSkip(int64(skip))
@@ -96,14 +110,25 @@ func (t *TaskResultRepository) List(
 	return tasks, nil
 }
 
-// Update updates a task result by ID in the database
+// Update updates the name, the score and the comment of a task result by ID in the database,
+// the updated task result is returned
 func (t *TaskResultRepository) Update(
 	ctx context.Context, task *taskResultDomain.TaskResultEntity,
 ) (*taskResultDomain.TaskResultEntity, error) {
 	var taskResult taskResultDomain.TaskResultEntity
-	opts := options.FindOneAndUpdate()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	filter := bson.D{{Key: "id", Value: task.ID}}
-	err := t.coll.FindOneAndUpdate(ctx, filter, opts).Decode(&taskResult)
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "name", Value: task.Name},
+		{Key: "score", Value: task.Score},
+		{Key: "comment", Value: task.Comment},
+		{Key: "updated_at", Value: task.UpdatedAt},
+	}}}
+	err := t.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&taskResult)
+	if err == mongo.ErrNoDocuments {
+		return task, errDomain.ErrDataNotFound
+	}
+
 	if err != nil {
 		return task, err
 	}
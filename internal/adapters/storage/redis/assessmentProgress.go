@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+
+	assessmentDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	cacheLib "github.com/lk153/quizgame-ai-serving/lib/cache"
+	errLib "github.com/lk153/quizgame-ai-serving/lib/errors"
+)
+
+const (
+	progressPrefix = "assessment:progress"
+)
+
+var _ ports.IAssessmentProgress = &AssessmentProgress{}
+
+/**
+ * AssessmentProgress implements port.IAssessmentProgress interface
+ * and broadcasts the job progress through redis pub/sub so that every replica receives it
+ */
+type AssessmentProgress struct {
+	rd *Redis
+}
+
+// NewAssessmentProgress creates a redis assessment progress instance
+func NewAssessmentProgress(rd *Redis) *AssessmentProgress {
+	return &AssessmentProgress{rd}
+}
+
+// Publish sends a progress event to the channel of its job
+func (p *AssessmentProgress) Publish(ctx context.Context, event assessmentDomain.ProgressEvent) error {
+	eventSerialized, err := cacheLib.Serialize(event)
+	if err != nil {
+		return err
+	}
+
+	return p.rd.client.Publish(ctx, cacheLib.GenerateCacheKey(progressPrefix, event.JobID), eventSerialized).Err()
+}
+
+// Subscribe listens to the channel of a job until the context is cancelled
+func (p *AssessmentProgress) Subscribe(
+	ctx context.Context, jobID string,
+) (<-chan assessmentDomain.ProgressEvent, error) {
+	pubsub := p.rd.client.Subscribe(ctx, cacheLib.GenerateCacheKey(progressPrefix, jobID))
+
+	// Wait for the subscription confirmation so that no event published afterward is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	events := make(chan assessmentDomain.ProgressEvent)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event assessmentDomain.ProgressEvent
+				if err := cacheLib.Deserialize([]byte(msg.Payload), &event); err != nil {
+					errLib.Error.Println(err)
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,295 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
+	assessmentDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	cacheLib "github.com/lk153/quizgame-ai-serving/lib/cache"
+	errLib "github.com/lk153/quizgame-ai-serving/lib/errors"
+)
+
+const (
+	pendingKey        = "assessment:queue:pending"
+	inflightKey       = "assessment:queue:inflight"
+	deadLetterKey     = "assessment:queue:dead"
+	leaseKey          = "assessment:queue:leases"
+	delayedKey        = "assessment:queue:delayed"
+	jobPrefix         = "assessment:job"
+	jobAttemptsPrefix = "assessment:job:attempts:"
+
+	defaultMaxAttempts       = 3
+	defaultVisibilityTimeout = 10 * time.Minute
+	jobTTL                   = 7 * 24 * time.Hour
+	pollInterval             = time.Second
+)
+
+var (
+	_ ports.IAssessmentQueue         = &AssessmentQueue{}
+	_ ports.IAssessmentJobRepository = &AssessmentQueue{}
+)
+
+var (
+	// claimScript pops the oldest job id and leases it until the visibility deadline,
+	// the lease token tells the worker which holds the job
+	claimScript = redis.NewScript(`
+local id = redis.call('RPOP', KEYS[1])
+if not id then
+	return false
+end
+redis.call('ZADD', KEYS[2], ARGV[1], id)
+redis.call('HSET', KEYS[3], id, ARGV[3])
+local attempts = redis.call('INCR', ARGV[2] .. id)
+return {id, attempts}
+`)
+
+	// ackScript removes the lease of a processed job, unless the lease expired and the job was claimed again
+	ackScript = redis.NewScript(`
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('DEL', ARGV[3] .. ARGV[1])
+return 1
+`)
+
+	// releaseScript delays a leased job until ARGV[5] or moves it to the dead-letter list,
+	// unless the lease expired and the job was claimed again
+	releaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[4], ARGV[1]) ~= ARGV[4] then
+	return -1
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[4], ARGV[1])
+local attempts = tonumber(redis.call('GET', ARGV[3] .. ARGV[1]) or '0')
+if attempts >= tonumber(ARGV[2]) then
+	redis.call('LPUSH', KEYS[3], ARGV[1])
+	return 1
+end
+redis.call('ZADD', KEYS[2], ARGV[5], ARGV[1])
+return 0
+`)
+
+	// deadLetterScript moves a leased job to the dead-letter list, unless the lease expired and the job was claimed again
+	deadLetterScript = redis.NewScript(`
+if redis.call('HGET', KEYS[3], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+redis.call('LPUSH', KEYS[2], ARGV[1])
+return 1
+`)
+
+	// promoteScript moves the delayed jobs which are due to the pending list
+	promoteScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('LPUSH', KEYS[2], id)
+end
+return #ids
+`)
+
+	// requeueScript releases the jobs whose lease expires and returns the dead-lettered ids
+	requeueScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+local dead = {}
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('HDEL', KEYS[4], id)
+	local attempts = tonumber(redis.call('GET', ARGV[3] .. id) or '0')
+	if attempts >= tonumber(ARGV[2]) then
+		redis.call('LPUSH', KEYS[3], id)
+		table.insert(dead, id)
+	else
+		redis.call('RPUSH', KEYS[2], id)
+	end
+end
+return dead
+`)
+)
+
+/**
+ * AssessmentQueue implements port.IAssessmentQueue and port.IAssessmentJobRepository interfaces
+ * and provides a durable queue on redis which is shared by several replicas.
+ * Claimed jobs are leased in a sorted set, an expired lease delivers the job again
+ */
+type AssessmentQueue struct {
+	client            *redis.Client
+	maxAttempts       int
+	visibilityTimeout time.Duration
+}
+
+// NewAssessmentQueue creates a redis assessment queue instance
+func NewAssessmentQueue(rd *Redis, config *config.Assessment) *AssessmentQueue {
+	maxAttempts := config.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	visibilityTimeout := config.VisibilityTimeout
+	if visibilityTimeout <= 0 {
+		visibilityTimeout = defaultVisibilityTimeout
+	}
+
+	return &AssessmentQueue{
+		client:            rd.client,
+		maxAttempts:       maxAttempts,
+		visibilityTimeout: visibilityTimeout,
+	}
+}
+
+// Enqueue pushes a job id to the pending list
+func (q *AssessmentQueue) Enqueue(ctx context.Context, job *assessmentDomain.Job) error {
+	return q.client.LPush(ctx, pendingKey, job.ID).Err()
+}
+
+// Dequeue claims the oldest pending job, it polls redis until a job is available or the context is done
+func (q *AssessmentQueue) Dequeue(ctx context.Context) (*assessmentDomain.Job, error) {
+	for {
+		if err := q.requeueExpired(ctx); err != nil {
+			errLib.Error.Println(err)
+		}
+
+		if err := promoteScript.Run(ctx, q.client, []string{delayedKey, pendingKey}, time.Now().UnixMilli()).Err(); err != nil {
+			errLib.Error.Println(err)
+		}
+
+		deadline := time.Now().Add(q.visibilityTimeout).UnixMilli()
+		lease := uuid.NewString()
+		res, err := claimScript.Run(ctx, q.client, []string{pendingKey, inflightKey, leaseKey},
+			deadline, jobAttemptsPrefix, lease).Slice()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+
+		if len(res) == 2 {
+			id, _ := res[0].(string)
+			attempts, _ := res[1].(int64)
+			job, err := q.GetByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+
+			job.Attempts = int(attempts)
+			job.Lease = lease
+			return job, nil
+		}
+
+		timer := time.NewTimer(pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Ack removes the lease of a processed job, a lease which expired and was claimed by another worker is kept
+func (q *AssessmentQueue) Ack(ctx context.Context, job *assessmentDomain.Job) error {
+	res, err := ackScript.Run(ctx, q.client, []string{inflightKey, leaseKey},
+		job.ID, job.Lease, jobAttemptsPrefix).Int()
+	if err != nil {
+		return err
+	}
+
+	if res == 0 {
+		errLib.Warn.Printf("assessment job %s lease has expired before it is acknowledged", job.ID)
+	}
+
+	return nil
+}
+
+// Nack delays a failed job or moves it to the dead-letter list,
+// the delayed job is moved back to the pending list by Dequeue once the delay is over
+func (q *AssessmentQueue) Nack(ctx context.Context, job *assessmentDomain.Job, delay time.Duration) (bool, error) {
+	res, err := releaseScript.Run(ctx, q.client, []string{inflightKey, delayedKey, deadLetterKey, leaseKey},
+		job.ID, q.maxAttempts, jobAttemptsPrefix, job.Lease, time.Now().Add(delay).UnixMilli()).Int()
+	if err != nil {
+		return false, err
+	}
+
+	if res < 0 {
+		errLib.Warn.Printf("assessment job %s lease has expired before it is released", job.ID)
+	}
+
+	return res == 1, nil
+}
+
+// DeadLetter moves a leased job to the dead-letter list
+func (q *AssessmentQueue) DeadLetter(ctx context.Context, job *assessmentDomain.Job) error {
+	res, err := deadLetterScript.Run(ctx, q.client, []string{inflightKey, deadLetterKey, leaseKey},
+		job.ID, job.Lease).Int()
+	if err != nil {
+		return err
+	}
+
+	if res == 0 {
+		errLib.Warn.Printf("assessment job %s lease has expired before it is dead-lettered", job.ID)
+	}
+
+	return nil
+}
+
+// Save stores an assessment job
+func (q *AssessmentQueue) Save(ctx context.Context, job *assessmentDomain.Job) error {
+	jobSerialized, err := cacheLib.Serialize(job)
+	if err != nil {
+		return err
+	}
+
+	return q.client.Set(ctx, cacheLib.GenerateCacheKey(jobPrefix, job.ID), jobSerialized, jobTTL).Err()
+}
+
+// GetByID gets an assessment job by id
+func (q *AssessmentQueue) GetByID(ctx context.Context, id string) (*assessmentDomain.Job, error) {
+	res, err := q.client.Get(ctx, cacheLib.GenerateCacheKey(jobPrefix, id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errDomain.ErrDataNotFound
+		}
+
+		return nil, err
+	}
+
+	var job assessmentDomain.Job
+	if err = cacheLib.Deserialize(res, &job); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// requeueExpired releases the jobs whose lease expires, dead-lettered jobs are marked as failed
+func (q *AssessmentQueue) requeueExpired(ctx context.Context) error {
+	dead, err := requeueScript.Run(ctx, q.client, []string{inflightKey, pendingKey, deadLetterKey, leaseKey},
+		time.Now().UnixMilli(), q.maxAttempts, jobAttemptsPrefix).StringSlice()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range dead {
+		job, err := q.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		job.Error = "assessment job lease has expired too many times"
+		job.SetStatus(assessmentDomain.JobFailed)
+		if err = q.Save(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
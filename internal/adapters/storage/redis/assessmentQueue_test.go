@@ -0,0 +1,206 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
+	assessmentDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+)
+
+func newTestQueue(t *testing.T, visibilityTimeout time.Duration) (*AssessmentQueue, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	rd, err := New(context.Background(), &config.Redis{Addr: server.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewAssessmentQueue(rd, &config.Assessment{MaxAttempts: 3, VisibilityTimeout: visibilityTimeout}), server
+}
+
+func enqueueTestJob(t *testing.T, q *AssessmentQueue) *assessmentDomain.Job {
+	t.Helper()
+	ctx := context.Background()
+	job := assessmentDomain.NewJob(assessmentDomain.InputTask{CandidateText: "essay"})
+	if err := q.Save(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Enqueue(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	return job
+}
+
+func TestAssessmentQueueAck(t *testing.T) {
+	ctx := context.Background()
+	q, server := newTestQueue(t, time.Minute)
+	enqueueTestJob(t, q)
+
+	job, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Lease == "" || job.Attempts != 1 {
+		t.Fatalf("Dequeue() job = %+v, want a lease and 1 attempt", job)
+	}
+
+	if err = q.Ack(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	if members, _ := server.ZMembers(inflightKey); len(members) != 0 {
+		t.Errorf("inflight = %v after Ack, want empty", members)
+	}
+
+	if server.Exists(jobAttemptsPrefix + job.ID) {
+		t.Error("attempts are kept after Ack")
+	}
+}
+
+func TestAssessmentQueueExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	q, server := newTestQueue(t, time.Minute)
+	enqueueTestJob(t, q)
+
+	// The first lease is already expired when it is claimed
+	q.visibilityTimeout = -time.Second
+
+	stale, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The job is delivered to another worker
+	q.visibilityTimeout = time.Minute
+	current, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current.ID != stale.ID || current.Lease == stale.Lease || current.Attempts != 2 {
+		t.Fatalf("Dequeue() job = %+v, want job %s claimed again", current, stale.ID)
+	}
+
+	// The first worker finishes late, the lease of the second worker is kept
+	if err = q.Ack(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	deadLettered, err := q.Nack(ctx, stale, 0)
+	if err != nil || deadLettered {
+		t.Fatalf("Nack() = %v, %v on a stale lease", deadLettered, err)
+	}
+
+	if members, _ := server.ZMembers(inflightKey); len(members) != 1 || members[0] != current.ID {
+		t.Fatalf("inflight = %v after the stale Ack, want [%s]", members, current.ID)
+	}
+
+	if delayed, _ := server.ZMembers(delayedKey); len(delayed) != 0 {
+		t.Fatalf("delayed = %v after the stale Nack, want empty", delayed)
+	}
+
+	if err = q.DeadLetter(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	if dead, _ := server.List(deadLetterKey); len(dead) != 0 {
+		t.Fatalf("dead-letter list = %v after the stale DeadLetter, want empty", dead)
+	}
+
+	if err = q.Ack(ctx, current); err != nil {
+		t.Fatal(err)
+	}
+
+	if members, _ := server.ZMembers(inflightKey); len(members) != 0 {
+		t.Errorf("inflight = %v after Ack, want empty", members)
+	}
+}
+
+func TestAssessmentQueueNack(t *testing.T) {
+	ctx := context.Background()
+	q, server := newTestQueue(t, time.Minute)
+	enqueueTestJob(t, q)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		job, err := q.Dequeue(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		deadLettered, err := q.Nack(ctx, job, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if deadLettered != (attempt == 3) {
+			t.Fatalf("Nack() attempt %d dead-lettered = %v", attempt, deadLettered)
+		}
+	}
+
+	if dead, _ := server.List(deadLetterKey); len(dead) != 1 {
+		t.Errorf("dead-letter list = %v, want the job", dead)
+	}
+}
+
+func TestAssessmentQueueNackDelay(t *testing.T) {
+	ctx := context.Background()
+	q, server := newTestQueue(t, time.Minute)
+	enqueued := enqueueTestJob(t, q)
+
+	job, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = q.Nack(ctx, job, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// The job is not delivered before its delay
+	claimCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if job, err = q.Dequeue(claimCtx); err == nil {
+		t.Fatalf("Dequeue() = %+v before the delay", job)
+	}
+
+	if delayed, _ := server.ZMembers(delayedKey); len(delayed) != 1 || delayed[0] != enqueued.ID {
+		t.Fatalf("delayed = %v, want the job", delayed)
+	}
+
+	// The job is due
+	server.ZAdd(delayedKey, float64(time.Now().Add(-time.Second).UnixMilli()), enqueued.ID)
+	job, err = q.Dequeue(ctx)
+	if err != nil || job.ID != enqueued.ID || job.Attempts != 2 {
+		t.Fatalf("Dequeue() = %+v, %v, want the job at its second attempt", job, err)
+	}
+}
+
+func TestAssessmentQueueDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	q, server := newTestQueue(t, time.Minute)
+	enqueueTestJob(t, q)
+
+	job, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = q.DeadLetter(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	if dead, _ := server.List(deadLetterKey); len(dead) != 1 || dead[0] != job.ID {
+		t.Errorf("dead-letter list = %v, want the job at its first attempt", dead)
+	}
+
+	if members, _ := server.ZMembers(inflightKey); len(members) != 0 {
+		t.Errorf("inflight = %v after DeadLetter, want empty", members)
+	}
+}
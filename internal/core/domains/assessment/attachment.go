@@ -0,0 +1,36 @@
+package assessment
+
+import (
+	"net/http"
+	"strings"
+
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+)
+
+// MaxAttachmentBytes bounds the size of a file attached to a candidate task
+const MaxAttachmentBytes = 4 << 20
+
+// Attachment represents a file which comes with a task, e.g. the chart of a Writing Task 1
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// NewChartAttachment creates the attachment of a chart, its content type is detected from the data
+func NewChartAttachment(name string, data []byte) (*Attachment, error) {
+	if len(data) > MaxAttachmentBytes {
+		return nil, errDomain.ErrAttachmentTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, errDomain.ErrUnsupportedAttachment
+	}
+
+	return &Attachment{
+		Name:        name,
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
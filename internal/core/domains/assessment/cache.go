@@ -0,0 +1,38 @@
+package assessment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/rubric"
+)
+
+// ContentHash identifies the content of a task which decides its assessment,
+// promptVersion is the version which the assessor resolves for the task, so that the default one is part of it.
+// The texts are compared with their whitespace collapsed, so that a resubmitted essay is recognised
+// even when it is reformatted. The case is kept, capitalisation errors are graded
+func (i InputTask) ContentHash(rb *rubric.Rubric, promptVersion string) string {
+	h := sha256.New()
+	for _, part := range []string{
+		rb.ID,
+		strings.TrimSpace(promptVersion),
+		normaliseText(i.TaskRequirement),
+		normaliseText(i.TaskFile),
+		normaliseText(i.CandidateText),
+	} {
+		h.Write([]byte(part))
+		// The separator keeps the parts apart, "ab"+"c" does not hash as "a"+"bc"
+		h.Write([]byte{0})
+	}
+
+	if i.Chart != nil {
+		h.Write(i.Chart.Data)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func normaliseText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,37 @@
+package assessment
+
+import (
+	"testing"
+)
+
+func TestContentHash(t *testing.T) {
+	task := InputTask{TaskType: 2, TaskRequirement: "Discuss both views.", CandidateText: "Some people think..."}
+	rb, err := task.ResolveRubric()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reformatted := task
+	reformatted.CandidateText = "  Some people\n think...  "
+	if task.ContentHash(rb, "prose-v1") != reformatted.ContentHash(rb, "prose-v1") {
+		t.Error("ContentHash() differs for a reformatted text")
+	}
+
+	// Capitalisation is graded, another case is another essay
+	recased := task
+	recased.CandidateText = "some people think..."
+	if task.ContentHash(rb, "prose-v1") == recased.ContentHash(rb, "prose-v1") {
+		t.Error("ContentHash() is the same for a text in another case")
+	}
+
+	// The resolved version is hashed, so that an empty version and the default one share the assessment
+	requested := task
+	requested.PromptVersion = "prose-v1"
+	if task.ContentHash(rb, "prose-v1") != requested.ContentHash(rb, "prose-v1") {
+		t.Error("ContentHash() differs for the requested and the resolved version")
+	}
+
+	if task.ContentHash(rb, "prose-v1") == task.ContentHash(rb, "prose-v2") {
+		t.Error("ContentHash() is the same for another resolved version")
+	}
+}
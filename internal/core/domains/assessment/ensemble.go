@@ -0,0 +1,189 @@
+package assessment
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/rubric"
+)
+
+// EnsembleMethod tells how the scores of several assessments are combined
+type EnsembleMethod string
+
+const (
+	// EnsembleMedian takes the median of the scores
+	EnsembleMedian EnsembleMethod = "median"
+	// EnsembleTrimmedMean drops the lowest and the highest scores before taking the mean
+	EnsembleTrimmedMean EnsembleMethod = "trimmed-mean"
+)
+
+const (
+	// DefaultSpreadThreshold is the spread of a score, in points of the scale, above which a result needs a human review
+	DefaultSpreadThreshold = 1.0
+	// trimRatio is the share of the scores dropped at each end by the trimmed mean
+	trimRatio = 0.2
+)
+
+// Ensemble describes how an assessment was combined from several runs
+type Ensemble struct {
+	Method EnsembleMethod `json:"method"`
+	// Runs is the number of assessments which were combined, Failed the number of runs which returned no assessment
+	Runs   int `json:"runs"`
+	Failed int `json:"failed"`
+	// Spread is the largest difference between the runs over the criteria and the overall score
+	Spread float64 `json:"spread"`
+	// NeedsReview tells the runs disagree too much, or too few of them succeeded, to trust the result
+	NeedsReview bool `json:"needs_review"`
+}
+
+// AggregateResults combines the assessments of several runs into one.
+// Each criterion is scored by the method over the runs which assessed it, a criterion assessed by fewer than half
+// of the runs is left out. The feedback is taken from the run closest to the combined overall score
+func AggregateResults(
+	results []*Result, failed int, method EnsembleMethod, threshold float64, rb *rubric.Rubric,
+) *Result {
+	if len(results) == 0 {
+		return nil
+	}
+
+	if threshold <= 0 {
+		threshold = DefaultSpreadThreshold
+	}
+
+	var names []string
+	scores := map[string][]float64{}
+	overall := make([]float64, 0, len(results))
+	for _, r := range results {
+		for _, c := range r.Details {
+			if _, ok := scores[c.Name]; !ok {
+				names = append(names, c.Name)
+			}
+
+			scores[c.Name] = append(scores[c.Name], rb.Normalise(c.BandScore))
+		}
+
+		overall = append(overall, rb.NormaliseOverall(r.OverallScore))
+	}
+
+	combined := &Result{
+		OverallScore: rb.NormaliseOverall(combine(overall, method)),
+		Ensemble: &Ensemble{
+			Method: method,
+			Runs:   len(results),
+			Failed: failed,
+			Spread: spread(overall),
+		},
+	}
+
+	pick := closest(results, combined.OverallScore, rb)
+	for _, name := range names {
+		values := scores[name]
+		if 2*len(values) < len(results) {
+			continue
+		}
+
+		criterion := Criterion{
+			Name:      name,
+			BandScore: rb.Normalise(combine(values, method)),
+			Spread:    spread(values),
+		}
+		for _, c := range pick.Details {
+			if c.Name == name {
+				criterion.HowToImprove = c.HowToImprove
+				criterion.Strengths = c.Strengths
+			}
+		}
+
+		combined.Details = append(combined.Details, criterion)
+		combined.Ensemble.Spread = math.Max(combined.Ensemble.Spread, criterion.Spread)
+	}
+
+	combined.SuggestEssay = pick.SuggestEssay
+	combined.PromptVersion = pick.PromptVersion
+	combined.Model, combined.Flags, combined.Suspicious = mergeOrigins(results)
+	// Every run is billed, not only the one whose feedback is kept
+	for _, r := range results {
+		combined.Usage = combined.Usage.Add(r.Usage)
+	}
+
+	// The disagreement of a single run can not be measured
+	combined.Ensemble.NeedsReview = combined.Ensemble.Spread > threshold ||
+		(failed > 0 && len(results) < 2)
+	return combined
+}
+
+// combine reduces the scores with the method, the median applies to an unknown method
+func combine(values []float64, method EnsembleMethod) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if method == EnsembleTrimmedMean {
+		trim := int(float64(n) * trimRatio)
+		if trim == 0 && n >= 3 {
+			trim = 1
+		}
+
+		sum := 0.0
+		for _, v := range sorted[trim : n-trim] {
+			sum += v
+		}
+
+		return sum / float64(n-2*trim)
+	}
+
+	if n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	return sorted[n/2]
+}
+
+func spread(values []float64) float64 {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+
+	return high - low
+}
+
+// closest returns the result whose overall score is the nearest to the score
+func closest(results []*Result, score float64, rb *rubric.Rubric) *Result {
+	pick := results[0]
+	for _, r := range results[1:] {
+		if math.Abs(rb.NormaliseOverall(r.OverallScore)-score) <
+			math.Abs(rb.NormaliseOverall(pick.OverallScore)-score) {
+			pick = r
+		}
+	}
+
+	return pick
+}
+
+// mergeOrigins joins the distinct models of the runs and their injection flags
+func mergeOrigins(results []*Result) (model string, flags []string, suspicious bool) {
+	var models []string
+	seenModels := map[string]bool{}
+	seenFlags := map[string]bool{}
+	for _, r := range results {
+		if !seenModels[r.Model] {
+			seenModels[r.Model] = true
+			models = append(models, r.Model)
+		}
+
+		for _, f := range r.Flags {
+			if !seenFlags[f] {
+				seenFlags[f] = true
+				flags = append(flags, f)
+			}
+		}
+
+		suspicious = suspicious || r.Suspicious
+	}
+
+	sort.Strings(flags)
+	return strings.Join(models, "+"), flags, suspicious
+}
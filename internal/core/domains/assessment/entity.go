@@ -0,0 +1,134 @@
+package assessment
+
+import (
+	"fmt"
+
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/rubric"
+	"github.com/lk153/quizgame-ai-serving/lib/essaymetrics"
+	"github.com/lk153/quizgame-ai-serving/lib/strings"
+)
+
+// InputTask represents a candidate task which is sent to an AI assessor
+type InputTask struct {
+	TaskType        uint8       `json:"task_type"`
+	TaskRequirement string      `json:"task_requirement"`
+	TaskFile        string      `json:"task_file"`
+	CandidateText   string      `json:"candidate_text"`
+	Chart           *Attachment `json:"chart,omitempty"`
+	// PromptVersion selects the prompt of the assessor, its default prompt applies when it is empty
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// Rubric is the id of the rubric which scores the task, the IELTS Writing rubric of the task type applies when it is empty
+	Rubric string `json:"rubric,omitempty"`
+	// ForceRefresh asks the assessor again even when the same content was assessed before
+	ForceRefresh bool `json:"force_refresh,omitempty"`
+}
+
+// ResolveRubric returns the rubric which scores the task
+func (i InputTask) ResolveRubric() (*rubric.Rubric, error) {
+	if strings.IsEmpty(i.Rubric) {
+		return rubric.ForIELTSWritingTask(i.TaskType), nil
+	}
+
+	return rubric.Get(i.Rubric)
+}
+
+// Criterion represents the assessment of a single scoring criterion
+type Criterion struct {
+	Name         string  `json:"name"`
+	BandScore    float64 `json:"band_score"`
+	HowToImprove string  `json:"how_to_improve"`
+	Strengths    string  `json:"strengths"`
+	// Penalty is the band deducted from the score given by the assessor, e.g. for an under-length response
+	Penalty float64 `json:"penalty,omitempty"`
+	// Spread is the difference between the highest and the lowest score of an ensemble
+	Spread float64 `json:"spread,omitempty"`
+}
+
+// Result represents the assessment returned by an AI assessor
+type Result struct {
+	Details      []Criterion `json:"details"`
+	OverallScore float64     `json:"overall_score"`
+	// ModelOverallScore is the overall band given by the assessor before it is recomputed from the criteria
+	ModelOverallScore float64 `json:"model_overall_score"`
+	// OverallMismatch tells the overall band of the assessor disagrees with its criteria
+	OverallMismatch bool   `json:"overall_mismatch"`
+	SuggestEssay    string `json:"suggest_essay"`
+	Model           string `json:"model"`
+	PromptVersion   string `json:"prompt_version"`
+	// Rubric is the id of the rubric which scored the task
+	Rubric string `json:"rubric"`
+	// Flags lists the injection attempts found in the candidate text
+	Flags []string `json:"flags,omitempty"`
+	// Suspicious tells the assessment could have been steered by the candidate text
+	Suspicious bool `json:"suspicious"`
+	// Metrics are computed locally from the candidate text
+	Metrics *essaymetrics.Metrics `json:"metrics,omitempty"`
+	// Ensemble is set when the result combines several runs, see AggregateResults
+	Ensemble *Ensemble `json:"ensemble,omitempty"`
+	// Usage counts the tokens spent on the assessment, it is zero when the assessor does not report it
+	Usage TokenUsage `json:"usage"`
+}
+
+// TokenUsage counts the tokens of the prompts and the completions of an assessment
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Add returns the sum of both usages
+func (u TokenUsage) Add(other TokenUsage) TokenUsage {
+	return TokenUsage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+	}
+}
+
+// Validate checks the scores are on the scale of the rubric
+func (r *Result) Validate(rb *rubric.Rubric) (isValid bool, err error) {
+	if len(r.Details) == 0 {
+		err = fmt.Errorf("assessment has no criteria")
+		return
+	}
+
+	for _, c := range r.Details {
+		if strings.IsEmpty(c.Name) {
+			err = fmt.Errorf("assessment criterion's name is empty")
+			return
+		}
+
+		if !rb.IsValid(c.BandScore) {
+			err = fmt.Errorf("band score %v of %s is out of range", c.BandScore, c.Name)
+			return
+		}
+	}
+
+	if !rb.IsValidOverall(r.OverallScore) {
+		err = fmt.Errorf("overall score %v is out of range", r.OverallScore)
+		return
+	}
+
+	isValid = true
+	return
+}
+
+// Normalise rounds the scores to the scale of the rubric and recomputes the overall score from the criteria,
+// the overall score of the assessor is kept to flag a disagreement
+func (r *Result) Normalise(rb *rubric.Rubric) {
+	for i := range r.Details {
+		r.Details[i].BandScore = rb.Normalise(r.Details[i].BandScore)
+	}
+
+	r.Rubric = rb.ID
+	r.ModelOverallScore = rb.NormaliseOverall(r.OverallScore)
+	r.OverallScore = r.overallBand(rb)
+	r.OverallMismatch = r.ModelOverallScore != r.OverallScore
+}
+
+func (r *Result) overallBand(rb *rubric.Rubric) float64 {
+	scores := make([]float64, 0, len(r.Details))
+	for _, c := range r.Details {
+		scores = append(scores, c.BandScore)
+	}
+
+	return rb.Overall(scores...)
+}
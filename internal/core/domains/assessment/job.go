@@ -0,0 +1,70 @@
+package assessment
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/auth"
+)
+
+// JobStatus represents the processing state of an assessment job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job represents an assessment which is processed in background
+type Job struct {
+	ID     string    `json:"id"`
+	Status JobStatus `json:"status"`
+	Stage  Stage     `json:"stage"`
+	Input  InputTask `json:"input"`
+	// Owner is the caller who queued the job, the task result is stored on their behalf
+	Owner        *auth.Principal `json:"owner,omitempty"`
+	TaskResultID string          `json:"task_result_id"`
+	Error        string          `json:"error"`
+	Attempts     int             `json:"attempts"`
+	// Lease identifies the claim of the worker which processes the job, it is not stored
+	Lease     string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewJob creates a queued job for the given task
+func NewJob(input InputTask) *Job {
+	now := time.Now().UTC()
+	return &Job{
+		ID:        uuid.NewString(),
+		Status:    JobQueued,
+		Input:     input,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// SetStatus moves the job to the given status
+func (j *Job) SetStatus(status JobStatus) {
+	j.Status = status
+	j.UpdatedAt = time.Now().UTC()
+}
+
+// IsDone reports whether the job reaches a final status
+func (j *Job) IsDone() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// NewProgressEvent creates a progress event from the current state of the job
+func (j *Job) NewProgressEvent(detail string) ProgressEvent {
+	return ProgressEvent{
+		JobID:  j.ID,
+		Status: j.Status,
+		Stage:  j.Stage,
+		Detail: detail,
+		Time:   time.Now().UTC(),
+	}
+}
@@ -0,0 +1,49 @@
+package assessment
+
+import (
+	"math"
+
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/rubric"
+	"github.com/lk153/quizgame-ai-serving/lib/essaymetrics"
+)
+
+// Names of the criteria which assess how a Writing task is fulfilled
+const (
+	CriterionTaskAchievement = "task-achievement"
+	CriterionTaskResponse    = "task-response"
+)
+
+// ApplyLengthPenalty lowers the task criterion of an under-length response and recomputes the overall band
+// when the rubric penalises the length. A response of 20 words or fewer is rated at band 1
+func (r *Result) ApplyLengthPenalty(m *essaymetrics.Metrics, rb *rubric.Rubric) {
+	if m == nil || !m.UnderLength || !rb.LengthPenalty {
+		return
+	}
+
+	if m.IsBandOneLength() {
+		for i := range r.Details {
+			r.Details[i].Penalty = math.Max(r.Details[i].BandScore-1, 0)
+			r.Details[i].BandScore = math.Min(r.Details[i].BandScore, 1)
+		}
+
+		r.OverallScore = math.Min(r.OverallScore, 1)
+		return
+	}
+
+	deduction := m.LengthPenalty()
+	penalised := false
+	for i := range r.Details {
+		c := &r.Details[i]
+		if c.Name != CriterionTaskAchievement && c.Name != CriterionTaskResponse {
+			continue
+		}
+
+		c.Penalty = math.Min(deduction, c.BandScore-rb.Scale.Min)
+		c.BandScore -= c.Penalty
+		penalised = penalised || c.Penalty > 0
+	}
+
+	if penalised {
+		r.OverallScore = r.overallBand(rb)
+	}
+}
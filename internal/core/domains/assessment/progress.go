@@ -0,0 +1,66 @@
+package assessment
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Stage represents a step of an assessment which is reported while the job is running
+type Stage string
+
+const (
+	StageSentToBot    Stage = "sent_to_bot"
+	StageWaiting      Stage = "waiting"
+	StagePartialReply Stage = "partial_reply"
+	StageParsed       Stage = "parsed"
+	StageStored       Stage = "stored"
+)
+
+// ProgressEvent represents a status change of an assessment job
+type ProgressEvent struct {
+	JobID  string    `json:"job_id"`
+	Status JobStatus `json:"status"`
+	Stage  Stage     `json:"stage"`
+	Detail string    `json:"detail"`
+	Time   time.Time `json:"time"`
+}
+
+// IsDone reports whether the event carries a final job status
+func (e ProgressEvent) IsDone() bool {
+	return e.Status == JobSucceeded || e.Status == JobFailed
+}
+
+// ProgressFunc receives the stages reported during an assessment
+type ProgressFunc func(stage Stage, detail string)
+
+type progressKey struct{}
+
+// WithProgress returns a context which carries the progress receiver of an assessment
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// WithSerialProgress returns a context whose progress receiver is called by one goroutine at a time,
+// so that the receiver of an assessment which runs in parallel does not need to be safe for concurrent use
+func WithSerialProgress(ctx context.Context) context.Context {
+	fn, ok := ctx.Value(progressKey{}).(ProgressFunc)
+	if !ok || fn == nil {
+		return ctx
+	}
+
+	var mu sync.Mutex
+	return WithProgress(ctx, func(stage Stage, detail string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		fn(stage, detail)
+	})
+}
+
+// ReportProgress sends a stage to the progress receiver of the context if there is one
+func ReportProgress(ctx context.Context, stage Stage, detail string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(stage, detail)
+	}
+}
@@ -0,0 +1,86 @@
+package assessment
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Names of the criteria of an IELTS Speaking test
+const (
+	CriterionFluencyCoherence = "fluency-and-coherence"
+	CriterionLexicalResource  = "lexical-resource"
+	CriterionGrammar          = "grammatical-range-and-accuracy"
+	CriterionPronunciation    = "pronunciation"
+)
+
+// PronunciationStatus tells whether the pronunciation could be judged from the transcript
+type PronunciationStatus string
+
+const (
+	// PronunciationReported means the assessor gave a band for the pronunciation
+	PronunciationReported PronunciationStatus = "reported"
+	// PronunciationUnknown means the assessor could not judge the pronunciation from the transcript
+	PronunciationUnknown PronunciationStatus = "unknown"
+)
+
+// SpeakingSegment is a timed turn of a Speaking test transcript, the times are in seconds from the start
+type SpeakingSegment struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Speaker string  `json:"speaker,omitempty"`
+	Text    string  `json:"text"`
+}
+
+// SpeakingInput represents a part of an IELTS Speaking test which is sent to an AI assessor
+type SpeakingInput struct {
+	// Part is 1 for the interview, 2 for the long turn and 3 for the discussion
+	Part uint8 `json:"part"`
+	// Prompts are the questions of the examiner, or the cue card of Part 2
+	Prompts    []string          `json:"prompts"`
+	Transcript string            `json:"transcript,omitempty"`
+	Segments   []SpeakingSegment `json:"segments,omitempty"`
+	// PromptVersion selects the prompt of the assessor, its default prompt applies when it is empty
+	PromptVersion string `json:"prompt_version,omitempty"`
+}
+
+// TranscriptText returns the transcript, it is built from the segments when only they are given.
+// Each segment is written on its own line as "[mm:ss-mm:ss] Speaker: text"
+func (i SpeakingInput) TranscriptText() string {
+	if strings.TrimSpace(i.Transcript) != "" || len(i.Segments) == 0 {
+		return strings.TrimSpace(i.Transcript)
+	}
+
+	lines := make([]string, 0, len(i.Segments))
+	for _, s := range i.Segments {
+		text := strings.TrimSpace(s.Text)
+		if text == "" {
+			continue
+		}
+
+		line := fmt.Sprintf("[%s-%s] ", formatSeconds(s.Start), formatSeconds(s.End))
+		if speaker := strings.TrimSpace(s.Speaker); speaker != "" {
+			line += speaker + ": "
+		}
+
+		lines = append(lines, line+text)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// SpeakingResult represents the assessment of a Speaking part returned by an AI assessor
+type SpeakingResult struct {
+	Result
+	// Pronunciation tells whether the details contain a pronunciation criterion,
+	// the overall band is computed from the other criteria when it is unknown
+	Pronunciation PronunciationStatus `json:"pronunciation"`
+}
+
+func formatSeconds(seconds float64) string {
+	total := int(seconds)
+	if total < 0 {
+		total = 0
+	}
+
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
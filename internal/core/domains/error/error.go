@@ -33,4 +33,28 @@ var (
 	ErrUnauthorized = errors.New("user is unauthorized to access the resource")
 	// ErrForbidden is an error for when the user is forbidden to access the resource
 	ErrForbidden = errors.New("user is forbidden to access the resource")
+	// ErrUnparsableAssessment is an error for when the AI assessor reply can not be parsed as an assessment
+	ErrUnparsableAssessment = errors.New("assessment reply can not be parsed")
+	// ErrQueueFull is an error for when the assessment queue can not take more jobs
+	ErrQueueFull = errors.New("assessment queue is full")
+	// ErrUnknownPromptVersion is an error for when the requested prompt version does not exist
+	ErrUnknownPromptVersion = errors.New("prompt version is not found")
+	// ErrUnknownRubric is an error for when the requested rubric does not exist
+	ErrUnknownRubric = errors.New("rubric is not found")
+	// ErrAssessorUnavailable is an error for when the AI assessor service fails or rejects the request
+	ErrAssessorUnavailable = errors.New("assessor service is unavailable")
+	// ErrAssessorRateLimited is an error for when the AI assessor service throttles the requests
+	ErrAssessorRateLimited = errors.New("assessor service is rate limited, please try again later")
+	// ErrAttachmentTooLarge is an error for when the file attached to a task exceeds the size limit
+	ErrAttachmentTooLarge = errors.New("attached file is too large")
+	// ErrUnsupportedAttachment is an error for when the file attached to a task is not of an accepted type
+	ErrUnsupportedAttachment = errors.New("attached file type is not supported")
+	// ErrInvalidIdempotencyKey is an error for when the Idempotency-Key header is too long
+	ErrInvalidIdempotencyKey = errors.New("idempotency key is invalid")
+	// ErrIdempotencyKeyReused is an error for when an idempotency key is sent again with another request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for another request")
+	// ErrRequestTooLarge is an error for when the request body exceeds the size limit
+	ErrRequestTooLarge = errors.New("request body is too large")
+	// ErrRequestInProgress is an error for when the request of an idempotency key is still being processed
+	ErrRequestInProgress = errors.New("request with this idempotency key is in progress")
 )
@@ -0,0 +1,193 @@
+package rubric
+
+import (
+	"sort"
+	"strings"
+
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/lib/essaymetrics"
+)
+
+// Ids of the built-in rubrics
+const (
+	IELTSWritingTask1       = "ielts-writing-task1"
+	IELTSWritingTask2       = "ielts-writing-task2"
+	IELTSSpeaking           = "ielts-speaking"
+	TOEFLIndependentWriting = "toefl-independent-writing"
+	CambridgeB2FirstWriting = "cambridge-b2-first-writing"
+	CEFRWriting             = "cefr-writing"
+)
+
+// ieltsScale is the IELTS band scale, from 0 to 9 in half bands
+var ieltsScale = Scale{Min: 0, Max: 9, Step: 0.5}
+
+var builtins = map[string]*Rubric{
+	IELTSWritingTask1: {
+		ID:    IELTSWritingTask1,
+		Title: "IELTS Writing Task 1",
+		Exam:  "ielts-writing",
+		Task:  "task1",
+		Criteria: criteria(
+			"Task Achievement", "Coherence and Cohesion", "Lexical Resource", "Grammatical Range and Accuracy",
+		),
+		Scale:         ieltsScale,
+		Rounding:      RoundHalfUp,
+		Aggregation:   AggregateMean,
+		MinWords:      essaymetrics.MinWordsTask1,
+		LengthPenalty: true,
+	},
+	IELTSWritingTask2: {
+		ID:    IELTSWritingTask2,
+		Title: "IELTS Writing Task 2",
+		Exam:  "ielts-writing",
+		Task:  "task2",
+		Criteria: criteria(
+			"Task Response", "Coherence and Cohesion", "Lexical Resource", "Grammatical Range and Accuracy",
+		),
+		Scale:         ieltsScale,
+		Rounding:      RoundHalfUp,
+		Aggregation:   AggregateMean,
+		MinWords:      essaymetrics.MinWordsTask2,
+		LengthPenalty: true,
+	},
+	IELTSSpeaking: {
+		ID:    IELTSSpeaking,
+		Title: "IELTS Speaking",
+		Exam:  "ielts-speaking",
+		Criteria: criteria(
+			"Fluency and Coherence", "Lexical Resource", "Grammatical Range and Accuracy", "Pronunciation",
+		),
+		Scale:       ieltsScale,
+		Rounding:    RoundHalfUp,
+		Aggregation: AggregateMean,
+	},
+	TOEFLIndependentWriting: {
+		ID:    TOEFLIndependentWriting,
+		Title: "TOEFL iBT Independent Writing",
+		Exam:  "toefl-writing",
+		Task:  "independent",
+		Criteria: []Criterion{
+			{
+				Name:        "development",
+				Title:       "Development",
+				Description: "how well the topic is addressed and the ideas are explained, exemplified and detailed",
+			},
+			{
+				Name:        "organization",
+				Title:       "Organization",
+				Description: "the unity, progression and coherence of the response",
+			},
+			{
+				Name:        "language-use",
+				Title:       "Language Use",
+				Description: "the variety of syntax, the choice of words and the grammatical accuracy",
+			},
+		},
+		Scale:       Scale{Min: 0, Max: 5, Step: 1},
+		Rounding:    RoundHalfUp,
+		Aggregation: AggregateMean,
+		MinWords:    300,
+	},
+	CambridgeB2FirstWriting: {
+		ID:    CambridgeB2FirstWriting,
+		Title: "Cambridge B2 First Writing",
+		Exam:  "cambridge-b2-first",
+		Task:  "writing",
+		Criteria: []Criterion{
+			{
+				Name:        "content",
+				Title:       "Content",
+				Description: "whether all the content is relevant and the target reader is fully informed",
+			},
+			{
+				Name:        "communicative-achievement",
+				Title:       "Communicative Achievement",
+				Description: "how well the conventions of the task are used to hold the attention of the target reader",
+			},
+			{
+				Name:        "organisation",
+				Title:       "Organisation",
+				Description: "how well the text is connected and organised with a variety of cohesive devices",
+			},
+			{
+				Name:        "language",
+				Title:       "Language",
+				Description: "the range and control of vocabulary and grammatical forms",
+			},
+		},
+		Scale:       Scale{Min: 0, Max: 5, Step: 1},
+		Rounding:    RoundHalfUp,
+		Aggregation: AggregateSum,
+		MinWords:    140,
+	},
+	CEFRWriting: {
+		ID:    CEFRWriting,
+		Title: "CEFR Writing",
+		Exam:  "cefr",
+		Task:  "writing",
+		Criteria: []Criterion{
+			{
+				Name:        "range",
+				Title:       "Range",
+				Description: "the range of vocabulary and structures which are used",
+			},
+			{
+				Name:        "coherence",
+				Title:       "Coherence",
+				Description: "how the ideas are linked into a clear and connected text",
+			},
+			{
+				Name:        "accuracy",
+				Title:       "Accuracy",
+				Description: "the grammatical control and the accuracy of the vocabulary",
+			},
+		},
+		Scale: Scale{Min: 1, Max: 6, Step: 1, Labels: []string{"A1", "A2", "B1", "B2", "C1", "C2"}},
+		// A level is given only when it is fully reached on average
+		Rounding:    RoundDown,
+		Aggregation: AggregateMean,
+	},
+}
+
+// Get returns the rubric of an id
+func Get(id string) (*Rubric, error) {
+	r, ok := builtins[id]
+	if !ok {
+		return nil, errDomain.ErrUnknownRubric
+	}
+
+	return r, nil
+}
+
+// ForIELTSWritingTask returns the IELTS Writing rubric of a task type, it applies when a task requests no rubric
+func ForIELTSWritingTask(taskType uint8) *Rubric {
+	if taskType == 1 {
+		return builtins[IELTSWritingTask1]
+	}
+
+	return builtins[IELTSWritingTask2]
+}
+
+// IDs returns the ids of the built-in rubrics
+func IDs() []string {
+	ids := make([]string, 0, len(builtins))
+	for id := range builtins {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// criteria creates the criteria of titles which need no description
+func criteria(titles ...string) []Criterion {
+	list := make([]Criterion, 0, len(titles))
+	for _, title := range titles {
+		list = append(list, Criterion{
+			Name:  strings.Join(strings.Fields(strings.ToLower(title)), "-"),
+			Title: title,
+		})
+	}
+
+	return list
+}
@@ -0,0 +1,55 @@
+package rubric
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// halfStep is added to a score spelled with "and a half" or "point five"
+const halfStep = 0.5
+
+var (
+	rangePattern  = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:-|–|—|to)\s*(\d+(?:\.\d+)?)`)
+	numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	wordPattern   = regexp.MustCompile(`(?i)\b(zero|one|two|three|four|five|six|seven|eight|nine)(\s+(?:and\s+a\s+half|point\s+five))?\b`)
+
+	numberWords = map[string]float64{
+		"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4,
+		"five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9,
+	}
+)
+
+// parseNumber reads a score returned as free text, e.g. "6.5", "Band 7", "6-6.5" or "seven".
+// A range gives its midpoint, the score is not brought to the scale
+func parseNumber(str string) (float64, error) {
+	if m := rangePattern.FindStringSubmatch(str); m != nil {
+		low, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, err
+		}
+
+		high, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return 0, err
+		}
+
+		return (low + high) / 2, nil
+	}
+
+	if match := numberPattern.FindString(str); match != "" {
+		return strconv.ParseFloat(match, 64)
+	}
+
+	if m := wordPattern.FindStringSubmatch(str); m != nil {
+		score := numberWords[strings.ToLower(m[1])]
+		if m[2] != "" {
+			score += halfStep
+		}
+
+		return score, nil
+	}
+
+	return 0, fmt.Errorf("band score %q has no number", str)
+}
@@ -0,0 +1,37 @@
+package rubric
+
+import "testing"
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		str     string
+		want    float64
+		wantErr bool
+	}{
+		{str: "6.5", want: 6.5},
+		{str: "Band 7", want: 7},
+		{str: "Band score: 8.0 (very good)", want: 8},
+		{str: "6-6.5", want: 6.25},
+		{str: "6 – 7", want: 6.5},
+		{str: "between 5 to 6", want: 5.5},
+		{str: "seven", want: 7},
+		{str: "Seven and a half", want: 7.5},
+		{str: "six point five", want: 6.5},
+		{str: "12", want: 12},
+		{str: "not assessed", wantErr: true},
+		{str: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got, err := parseNumber(tt.str)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNumber(%q) error = %v, want error %v", tt.str, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("parseNumber(%q) = %v, want %v", tt.str, got, tt.want)
+			}
+		})
+	}
+}
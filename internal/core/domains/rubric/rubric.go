@@ -0,0 +1,226 @@
+// Package rubric describes how an exam scores a response: its criteria, its scale,
+// how a score is rounded to the scale and how the criteria make the overall score
+package rubric
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Rounding tells how a score is brought to a step of the scale
+type Rounding string
+
+const (
+	// RoundHalfUp rounds to the nearest step, a score halfway between two steps is rounded up
+	RoundHalfUp Rounding = "half-up"
+	// RoundDown keeps the highest step which is fully reached
+	RoundDown Rounding = "down"
+)
+
+// Aggregation tells how the scores of the criteria make the overall score
+type Aggregation string
+
+const (
+	// AggregateMean gives the mean of the criteria, rounded to the scale
+	AggregateMean Aggregation = "mean"
+	// AggregateSum gives the total of the criteria, the overall scale grows with the number of criteria
+	AggregateSum Aggregation = "sum"
+)
+
+// epsilon absorbs the float errors of the step arithmetic
+const epsilon = 1e-9
+
+// Scale is the range of the scores of a criterion
+type Scale struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Step float64 `json:"step"`
+	// Labels name the steps from Min upwards, e.g. the CEFR levels. A scale without labels is numeric
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Criterion is a scored aspect of a response
+type Criterion struct {
+	// Name identifies the criterion in the results, it is the lower-cased title joined by dashes
+	Name string `json:"name"`
+	// Title is the criterion as it is written in the prompt and in the reply
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// Rubric describes how a response is scored
+type Rubric struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Exam and Task select the prompt template, a template generated from the rubric applies when there is none
+	Exam        string      `json:"exam"`
+	Task        string      `json:"task"`
+	Criteria    []Criterion `json:"criteria"`
+	Scale       Scale       `json:"scale"`
+	Rounding    Rounding    `json:"rounding"`
+	Aggregation Aggregation `json:"aggregation"`
+	// MinWords is the expected length of the response, no length is expected when it is zero
+	MinWords int `json:"min_words"`
+	// LengthPenalty lowers the task criterion of an under-length response
+	LengthPenalty bool `json:"length_penalty"`
+
+	labelOnce    sync.Once
+	labelPattern *regexp.Regexp
+}
+
+// Parse reads the score of a criterion returned as free text, a label of the scale or a number, and normalises it
+func (r *Rubric) Parse(str string) (float64, error) {
+	return r.parse(str, r.Normalise)
+}
+
+// ParseOverall reads the overall score returned as free text and normalises it to the overall scale
+func (r *Rubric) ParseOverall(str string) (float64, error) {
+	return r.parse(str, r.NormaliseOverall)
+}
+
+// Normalise clamps a score of a criterion to the scale and rounds it to a step
+func (r *Rubric) Normalise(score float64) float64 {
+	return r.round(score, r.Scale.Min, r.Scale.Max)
+}
+
+// Overall returns the overall score of the criteria
+func (r *Rubric) Overall(scores ...float64) float64 {
+	if len(scores) == 0 {
+		return r.Scale.Min
+	}
+
+	sum := 0.0
+	for _, s := range scores {
+		sum += s
+	}
+
+	if r.Aggregation == AggregateSum {
+		return r.NormaliseOverall(sum)
+	}
+
+	return r.Normalise(sum / float64(len(scores)))
+}
+
+// NormaliseOverall clamps an overall score to the overall scale and rounds it to a step
+func (r *Rubric) NormaliseOverall(score float64) float64 {
+	scale := r.OverallScale()
+	return r.round(score, scale.Min, scale.Max)
+}
+
+// OverallScale returns the range of the overall score
+func (r *Rubric) OverallScale() Scale {
+	if r.Aggregation != AggregateSum {
+		return r.Scale
+	}
+
+	n := float64(len(r.Criteria))
+	return Scale{Min: r.Scale.Min * n, Max: r.Scale.Max * n, Step: r.Scale.Step}
+}
+
+// IsValid reports whether the score of a criterion is a step of the scale
+func (r *Rubric) IsValid(score float64) bool {
+	return onScale(score, r.Scale)
+}
+
+// IsValidOverall reports whether the overall score is a step of the overall scale
+func (r *Rubric) IsValidOverall(score float64) bool {
+	return onScale(score, r.OverallScale())
+}
+
+// Label returns the label of a score, it is empty on a numeric scale
+func (r *Rubric) Label(score float64) string {
+	if len(r.Scale.Labels) == 0 || !r.IsValid(score) {
+		return ""
+	}
+
+	return r.Scale.Labels[int(math.Round((score-r.Scale.Min)/r.Scale.Step))]
+}
+
+// Describe tells the scale in words, it is written in the prompt
+func (r *Rubric) Describe() string {
+	if len(r.Scale.Labels) > 0 {
+		return fmt.Sprintf("with one of the levels %s, from the lowest to the highest",
+			strings.Join(r.Scale.Labels, ", "))
+	}
+
+	return fmt.Sprintf("from %s to %s in steps of %s",
+		formatScore(r.Scale.Min), formatScore(r.Scale.Max), formatScore(r.Scale.Step))
+}
+
+func (r *Rubric) parse(str string, normalise func(float64) float64) (float64, error) {
+	if len(r.Scale.Labels) > 0 {
+		if score, ok := r.parseLabel(str); ok {
+			return score, nil
+		}
+	}
+
+	score, err := parseNumber(str)
+	if err != nil {
+		return 0, err
+	}
+
+	return normalise(score), nil
+}
+
+func (r *Rubric) parseLabel(str string) (float64, bool) {
+	m := r.labelRegexp().FindStringSubmatch(str)
+	if m == nil {
+		return 0, false
+	}
+
+	for i, l := range r.Scale.Labels {
+		if strings.EqualFold(l, m[1]) {
+			return r.Scale.Min + float64(i)*r.Scale.Step, true
+		}
+	}
+
+	return 0, false
+}
+
+// labelRegexp compiles the pattern of the labels once, at the first parse
+func (r *Rubric) labelRegexp() *regexp.Regexp {
+	r.labelOnce.Do(func() {
+		quoted := make([]string, 0, len(r.Scale.Labels))
+		for _, l := range r.Scale.Labels {
+			quoted = append(quoted, regexp.QuoteMeta(l))
+		}
+
+		// The labels are matched as whole words, e.g. "B2" in "Level B2+" but not in "AB2"
+		r.labelPattern = regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+	})
+
+	return r.labelPattern
+}
+
+func (r *Rubric) round(score float64, min float64, max float64) float64 {
+	if math.IsNaN(score) {
+		return min
+	}
+
+	score = math.Max(min, math.Min(max, score))
+	steps := (score - min) / r.Scale.Step
+	if r.Rounding == RoundDown {
+		steps = math.Floor(steps + epsilon)
+	} else {
+		steps = math.Floor(steps + 0.5)
+	}
+
+	return min + steps*r.Scale.Step
+}
+
+func onScale(score float64, scale Scale) bool {
+	if score < scale.Min || score > scale.Max {
+		return false
+	}
+
+	steps := (score - scale.Min) / scale.Step
+	return math.Abs(steps-math.Round(steps)) < epsilon
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
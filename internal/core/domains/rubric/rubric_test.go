@@ -0,0 +1,222 @@
+package rubric
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	ielts := builtins[IELTSWritingTask2]
+	cefr := builtins[CEFRWriting]
+	tests := []struct {
+		name   string
+		rubric *Rubric
+		score  float64
+		want   float64
+	}{
+		{name: "half-up on a step", rubric: ielts, score: 6.5, want: 6.5},
+		{name: "half-up below a quarter", rubric: ielts, score: 6.125, want: 6},
+		{name: "half-up a quarter", rubric: ielts, score: 6.25, want: 6.5},
+		{name: "half-up three quarters", rubric: ielts, score: 6.75, want: 7},
+		{name: "half-up under the scale", rubric: ielts, score: -1, want: 0},
+		{name: "half-up over the scale", rubric: ielts, score: 9.75, want: 9},
+		{name: "half-up not a number", rubric: ielts, score: math.NaN(), want: 0},
+		{name: "down keeps the reached step", rubric: cefr, score: 4.99, want: 4},
+		{name: "down on a step with float errors", rubric: cefr, score: (4.1 + 4.9 + 6) / 3, want: 5},
+		{name: "down under the scale", rubric: cefr, score: 0, want: 1},
+		{name: "down over the scale", rubric: cefr, score: 7, want: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rubric.Normalise(tt.score); got != tt.want {
+				t.Errorf("Normalise(%v) = %v, want %v", tt.score, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	ielts := builtins[IELTSWritingTask2]
+	tests := []struct {
+		str     string
+		want    float64
+		wantErr bool
+	}{
+		{str: "Band 7", want: 7},
+		{str: "6-6.5", want: 6.5},
+		{str: "seven and a half", want: 7.5},
+		{str: "6.3", want: 6.5},
+		{str: "band 12", want: 9},
+		{str: "no band given", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got, err := ielts.Parse(tt.str)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.str, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %v, want %v", tt.str, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateSum(t *testing.T) {
+	cambridge := builtins[CambridgeB2FirstWriting]
+	if scale := cambridge.OverallScale(); scale.Min != 0 || scale.Max != 20 || scale.Step != 1 {
+		t.Fatalf("OverallScale() = %+v, want 0-20 in steps of 1", scale)
+	}
+
+	tests := []struct {
+		name   string
+		scores []float64
+		want   float64
+	}{
+		{name: "total", scores: []float64{5, 4, 3, 4}, want: 16},
+		{name: "full marks", scores: []float64{5, 5, 5, 5}, want: 20},
+		{name: "half marks are rounded up", scores: []float64{3.5, 4, 4, 4}, want: 16},
+		{name: "over the scale", scores: []float64{6, 6, 6, 6}, want: 20},
+		{name: "no score", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cambridge.Overall(tt.scores...); got != tt.want {
+				t.Errorf("Overall(%v) = %v, want %v", tt.scores, got, tt.want)
+			}
+		})
+	}
+
+	if got, err := cambridge.ParseOverall("Total: 17 out of 20"); err != nil || got != 17 {
+		t.Errorf("ParseOverall() = %v, %v, want 17", got, err)
+	}
+
+	// A criterion stays on its own scale
+	if got, err := cambridge.Parse("17"); err != nil || got != 5 {
+		t.Errorf("Parse() = %v, %v, want 5", got, err)
+	}
+
+	if !cambridge.IsValidOverall(20) || cambridge.IsValidOverall(21) || cambridge.IsValid(6) {
+		t.Errorf("IsValidOverall(20), IsValidOverall(21), IsValid(6) = %v, %v, %v, want true, false, false",
+			cambridge.IsValidOverall(20), cambridge.IsValidOverall(21), cambridge.IsValid(6))
+	}
+}
+
+func TestCEFR(t *testing.T) {
+	cefr := builtins[CEFRWriting]
+	tests := []struct {
+		name      string
+		scores    []float64
+		want      float64
+		wantLabel string
+	}{
+		{name: "level not fully reached", scores: []float64{4, 4, 5}, want: 4, wantLabel: "B2"},
+		{name: "level reached", scores: []float64{5, 5, 5}, want: 5, wantLabel: "C1"},
+		{name: "one level below", scores: []float64{6, 6, 5}, want: 5, wantLabel: "C1"},
+		{name: "lowest level", scores: []float64{1, 1, 2}, want: 1, wantLabel: "A1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cefr.Overall(tt.scores...)
+			if got != tt.want || cefr.Label(got) != tt.wantLabel {
+				t.Errorf("Overall(%v) = %v (%q), want %v (%q)", tt.scores, got, cefr.Label(got), tt.want, tt.wantLabel)
+			}
+		})
+	}
+
+	if label := cefr.Label(4.5); label != "" {
+		t.Errorf("Label(4.5) = %q, want no label off the scale", label)
+	}
+}
+
+func TestParseLabel(t *testing.T) {
+	cefr := builtins[CEFRWriting]
+	tests := []struct {
+		str    string
+		want   float64
+		wantOK bool
+	}{
+		{str: "B2", want: 4, wantOK: true},
+		{str: "Level: b2+", want: 4, wantOK: true},
+		{str: "**C1** (advanced)", want: 5, wantOK: true},
+		{str: "A1", want: 1, wantOK: true},
+		{str: "AB2"},
+		{str: "B22"},
+		{str: "upper intermediate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got, ok := cefr.parseLabel(tt.str)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseLabel(%q) = %v, %v, want %v, %v", tt.str, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	// A label wins over the digit in it, a text without a label is read as a number
+	if got, err := cefr.Parse("B1"); err != nil || got != 3 {
+		t.Errorf("Parse(B1) = %v, %v, want 3", got, err)
+	}
+
+	if got, err := cefr.Parse("level 5"); err != nil || got != 5 {
+		t.Errorf("Parse(level 5) = %v, %v, want 5", got, err)
+	}
+}
+
+func TestParseLabelConcurrently(t *testing.T) {
+	r := &Rubric{Scale: Scale{Min: 1, Max: 3, Step: 1, Labels: []string{"low", "mid", "high"}}, Rounding: RoundDown}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := r.Parse("Mid"); err != nil || got != 2 {
+				t.Errorf("Parse(Mid) = %v, %v, want 2", got, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestTOEFL(t *testing.T) {
+	toefl := builtins[TOEFLIndependentWriting]
+	tests := []struct {
+		name   string
+		scores []float64
+		want   float64
+	}{
+		{name: "mean below a half", scores: []float64{4, 5, 4}, want: 4},
+		{name: "mean above a half", scores: []float64{4, 5, 5}, want: 5},
+		{name: "mean on a half", scores: []float64{3, 4}, want: 4},
+		{name: "lowest", scores: []float64{0, 0, 1}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toefl.Overall(tt.scores...); got != tt.want {
+				t.Errorf("Overall(%v) = %v, want %v", tt.scores, got, tt.want)
+			}
+		})
+	}
+
+	if scale := toefl.OverallScale(); scale.Min != 0 || scale.Max != 5 || scale.Step != 1 {
+		t.Errorf("OverallScale() = %+v, want the scale of the criteria", toefl.OverallScale())
+	}
+
+	if toefl.IsValid(4.5) || !toefl.IsValid(4) {
+		t.Errorf("IsValid(4.5), IsValid(4) = %v, %v, want false, true", toefl.IsValid(4.5), toefl.IsValid(4))
+	}
+
+	if got := toefl.Describe(); got != "from 0 to 5 in steps of 1" {
+		t.Errorf("Describe() = %q", got)
+	}
+}
@@ -2,17 +2,62 @@ package taskresult
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/lk153/quizgame-ai-serving/lib/essaymetrics"
 	"github.com/lk153/quizgame-ai-serving/lib/strings"
 )
 
 type TaskResultEntity struct {
-	ID      string  `bson:"id" json:"id" example:"35f1b935-58b1-42ed-8eea-10062906b84f"`
-	Name    string  `bson:"name" json:"name"`
-	Score   float64 `bson:"score" json:"score"`
-	Comment string  `bson:"comment" json:"comment"`
+	ID string `bson:"id" json:"id" example:"35f1b935-58b1-42ed-8eea-10062906b84f"`
+	// OwnerID is the subject of the caller who submitted the task, ClassIDs are the classes of the caller then
+	OwnerID  string   `bson:"owner_id" json:"owner_id"`
+	ClassIDs []string `bson:"class_ids" json:"class_ids"`
+	Name     string   `bson:"name" json:"name"`
+	Exam     string   `bson:"exam" json:"exam"`
+	Rubric   string   `bson:"rubric" json:"rubric"`
+	Score    float64  `bson:"score" json:"score"`
+	// Level is the label of the score on a labelled scale, e.g. a CEFR level
+	Level           string                `bson:"level" json:"level"`
+	ModelScore      float64               `bson:"model_score" json:"model_score"`
+	ScoreMismatch   bool                  `bson:"score_mismatch" json:"score_mismatch"`
+	Comment         string                `bson:"comment" json:"comment"`
+	TaskType        uint8                 `bson:"task_type" json:"task_type"`
+	TaskRequirement string                `bson:"task_requirement" json:"task_requirement"`
+	CandidateText   string                `bson:"candidate_text" json:"candidate_text"`
+	Criteria        []CriterionScore      `bson:"criteria" json:"criteria"`
+	SuggestEssay    string                `bson:"suggest_essay" json:"suggest_essay"`
+	Model           string                `bson:"model" json:"model"`
+	PromptVersion   string                `bson:"prompt_version" json:"prompt_version"`
+	Flags           []string              `bson:"flags" json:"flags"`
+	Suspicious      bool                  `bson:"suspicious" json:"suspicious"`
+	Metrics         *essaymetrics.Metrics `bson:"metrics" json:"metrics"`
+	// Runs is the number of assessments combined into the score, Spread is how much they disagree
+	Runs   int     `bson:"runs" json:"runs"`
+	Spread float64 `bson:"spread" json:"spread"`
+	// NeedsReview tells the score has to be checked by a human examiner
+	NeedsReview bool `bson:"needs_review" json:"needs_review"`
+	// CacheHit tells the assessment was reused from an earlier submission of the same content
+	CacheHit bool `bson:"cache_hit" json:"cache_hit"`
+	// Pronunciation tells whether a Speaking assessment has a pronunciation band, see assessment.PronunciationStatus
+	Pronunciation string `bson:"pronunciation" json:"pronunciation"`
+	// PromptTokens and CompletionTokens count the tokens spent on the assessment by every run
+	PromptTokens     int       `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int       `bson:"completion_tokens" json:"completion_tokens"`
+	CreatedAt        time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// CriterionScore represents the band score of a single assessed criterion
+type CriterionScore struct {
+	Name         string  `bson:"name" json:"name"`
+	BandScore    float64 `bson:"band_score" json:"band_score"`
+	HowToImprove string  `bson:"how_to_improve" json:"how_to_improve"`
+	Strengths    string  `bson:"strengths" json:"strengths"`
+	Penalty      float64 `bson:"penalty" json:"penalty"`
+	Spread       float64 `bson:"spread" json:"spread"`
 }
 
 func init() {
@@ -47,3 +92,15 @@ func (u *TaskResultEntity) Validate() (isValid bool, err error) {
 	isValid = true
 	return
 }
+
+// ListFilter narrows a list of task results to the ones owned by OwnerID or submitted in one of ClassIDs,
+// an empty filter matches every task result
+type ListFilter struct {
+	OwnerID  string
+	ClassIDs []string
+}
+
+// IsEmpty tells the filter matches every task result
+func (f ListFilter) IsEmpty() bool {
+	return f.OwnerID == "" && len(f.ClassIDs) == 0
+}
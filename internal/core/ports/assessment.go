@@ -0,0 +1,84 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	taskResultEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
+	"github.com/lk153/quizgame-ai-serving/lib/essaymetrics"
+)
+
+//go:generate mockgen -source=assessment.go -destination=mocks/assessment.go -package=mocks
+
+// IAssessor is an interface for interacting with an AI model backend which assesses candidate tasks
+type IAssessor interface {
+	// Assess sends the task to the AI model and returns its assessment
+	Assess(ctx context.Context, input assessmentEntities.InputTask) (*assessmentEntities.Result, error)
+
+	// AssessSpeaking sends the transcript of a Speaking part to the AI model and returns its assessment
+	AssessSpeaking(ctx context.Context, input assessmentEntities.SpeakingInput) (*assessmentEntities.SpeakingResult, error)
+
+	// PromptVersion returns the version of the prompt which is sent for the requested version, e.g. the default one when none is
+	PromptVersion(requested string) string
+}
+
+// IAssessmentService is an interface for interacting with related assessment business logic
+type IAssessmentService interface {
+	// AssessTask assesses a candidate task with the configured AI assessor and stores its result
+	AssessTask(ctx context.Context, input assessmentEntities.InputTask) (*taskResultEntities.TaskResultEntity, error)
+
+	// AssessSpeaking assesses a Speaking part from its transcript and stores its result
+	AssessSpeaking(ctx context.Context, input assessmentEntities.SpeakingInput) (*taskResultEntities.TaskResultEntity, error)
+
+	// MeasureTask computes the metrics of a candidate text without the AI assessor
+	MeasureTask(ctx context.Context, input assessmentEntities.InputTask) (*essaymetrics.Metrics, error)
+
+	// EnqueueTask queues a candidate task to be assessed in background
+	EnqueueTask(ctx context.Context, input assessmentEntities.InputTask) (*assessmentEntities.Job, error)
+
+	// GetJob returns an assessment job by id
+	GetJob(ctx context.Context, id string) (*assessmentEntities.Job, error)
+
+	// WatchJob returns the progress of an assessment job, starting with its current status.
+	// The channel is closed when the job is done or the context is cancelled
+	WatchJob(ctx context.Context, id string) (<-chan assessmentEntities.ProgressEvent, error)
+}
+
+// IAssessmentQueue is an interface for queueing assessment jobs which are processed in background
+type IAssessmentQueue interface {
+	// Enqueue pushes a job to the queue
+	Enqueue(ctx context.Context, job *assessmentEntities.Job) error
+
+	// Dequeue claims a job from the queue, it blocks until a job is available or the context is done.
+	// The claim expires after the visibility timeout and the job is delivered again unless it is acknowledged
+	Dequeue(ctx context.Context) (*assessmentEntities.Job, error)
+
+	// Ack removes a processed job from the queue
+	Ack(ctx context.Context, job *assessmentEntities.Job) error
+
+	// Nack releases a failed job back to the queue, it is delivered again once the delay is over.
+	// The job is moved to the dead-letter list instead when it reaches the maximum attempts
+	Nack(ctx context.Context, job *assessmentEntities.Job, delay time.Duration) (deadLettered bool, err error)
+
+	// DeadLetter moves a job which can not succeed to the dead-letter list, whatever its attempts
+	DeadLetter(ctx context.Context, job *assessmentEntities.Job) error
+}
+
+// IAssessmentJobRepository is an interface for interacting with assessment job statuses
+type IAssessmentJobRepository interface {
+	// Save inserts or replaces an assessment job
+	Save(ctx context.Context, job *assessmentEntities.Job) error
+
+	// GetByID selects an assessment job by id
+	GetByID(ctx context.Context, id string) (*assessmentEntities.Job, error)
+}
+
+// IAssessmentProgress is an interface for broadcasting the progress of assessment jobs
+type IAssessmentProgress interface {
+	// Publish sends a progress event to the subscribers of its job
+	Publish(ctx context.Context, event assessmentEntities.ProgressEvent) error
+
+	// Subscribe listens to the progress events of a job until the context is cancelled
+	Subscribe(ctx context.Context, jobID string) (<-chan assessmentEntities.ProgressEvent, error)
+}
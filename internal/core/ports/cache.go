@@ -12,6 +12,9 @@ type ICacheRepository interface {
 	// Set stores the value in the cache
 	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
 
+	// SetIfAbsent stores the value only when the key does not exist, it reports whether the value was stored
+	SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
 	// Get retrieves the value from the cache
 	Get(ctx context.Context, key string) ([]byte, error)
 
@@ -0,0 +1,35 @@
+package ports
+
+import (
+	"context"
+
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	taskResultEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
+)
+
+// ITaskResultPolicy is an interface for deciding what the caller of the context may do with the task results
+type ITaskResultPolicy interface {
+	// CanCreate checks the caller may submit a task result, which is then owned by the caller
+	CanCreate(ctx context.Context) error
+
+	// CanRead checks the caller may see the task result
+	CanRead(ctx context.Context, taskResult *taskResultEntities.TaskResultEntity) error
+
+	// CanUpdate checks the caller may change the task result
+	CanUpdate(ctx context.Context, taskResult *taskResultEntities.TaskResultEntity) error
+
+	// CanDelete checks the caller may delete the task result
+	CanDelete(ctx context.Context, taskResult *taskResultEntities.TaskResultEntity) error
+
+	// ListFilter returns the filter of the task results which the caller may list
+	ListFilter(ctx context.Context) (taskResultEntities.ListFilter, error)
+}
+
+// IAssessmentPolicy is an interface for deciding what the caller of the context may do with the assessments
+type IAssessmentPolicy interface {
+	// CanAssess checks the caller may have a task assessed, which stores a task result owned by the caller
+	CanAssess(ctx context.Context) error
+
+	// CanReadJob checks the caller may see an assessment job and follow its progress
+	CanReadJob(ctx context.Context, job *assessmentEntities.Job) error
+}
@@ -16,8 +16,10 @@ type ITaskResultRepository interface {
 	// GetByID selects a task result by id
 	GetByID(ctx context.Context, id string) (*taskResultEntities.TaskResultEntity, error)
 
-	// List selects a list of task results with pagination
-	List(ctx context.Context, skip, limit uint64) ([]taskResultEntities.TaskResultEntity, error)
+	// List selects a list of the task results matching the filter with pagination
+	List(
+		ctx context.Context, filter taskResultEntities.ListFilter, skip, limit uint64,
+	) ([]taskResultEntities.TaskResultEntity, error)
 
 	// Update updates a task result
 	Update(ctx context.Context, taskResult *taskResultEntities.TaskResultEntity) (*taskResultEntities.TaskResultEntity, error)
@@ -0,0 +1,252 @@
+package assessment
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	authEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/auth"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	errLib "github.com/lk153/quizgame-ai-serving/lib/errors"
+)
+
+const (
+	defaultWorkers      = 2
+	defaultJobTimeout   = 5 * time.Minute
+	defaultRetryBackoff = 30 * time.Second
+	maxRetryBackoff     = 10 * time.Minute
+)
+
+// jobErrors are the domain errors which a failed job reports as they are
+var jobErrors = []error{
+	errDomain.ErrAssessorRateLimited,
+	errDomain.ErrAssessorUnavailable,
+	errDomain.ErrUnparsableAssessment,
+	errDomain.ErrUnknownPromptVersion,
+	errDomain.ErrUnknownRubric,
+	errDomain.ErrUnauthorized,
+	errDomain.ErrForbidden,
+}
+
+// permanentErrors fail again whenever the job is retried, the job is dead-lettered at once
+var permanentErrors = []error{
+	errDomain.ErrUnparsableAssessment,
+	errDomain.ErrUnknownPromptVersion,
+	errDomain.ErrUnknownRubric,
+	errDomain.ErrUnauthorized,
+	errDomain.ErrForbidden,
+}
+
+// RunnerOptions contains the settings of the background assessment workers
+type RunnerOptions struct {
+	Workers    int
+	JobTimeout time.Duration
+	// RetryBackoff is the delay before the second attempt of a failed job, it doubles at every attempt
+	RetryBackoff time.Duration
+}
+
+// Runner pulls assessment jobs from the queue and processes them with a pool of workers
+type Runner struct {
+	svc      ports.IAssessmentService
+	queue    ports.IAssessmentQueue
+	jobs     ports.IAssessmentJobRepository
+	progress ports.IAssessmentProgress
+	opts     RunnerOptions
+
+	wg         sync.WaitGroup
+	stopIntake context.CancelFunc
+	cancelJobs context.CancelFunc
+}
+
+func NewRunner(
+	svc ports.IAssessmentService,
+	queue ports.IAssessmentQueue,
+	jobs ports.IAssessmentJobRepository,
+	progress ports.IAssessmentProgress,
+	opts RunnerOptions,
+) *Runner {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+
+	if opts.JobTimeout <= 0 {
+		opts.JobTimeout = defaultJobTimeout
+	}
+
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+
+	return &Runner{
+		svc:      svc,
+		queue:    queue,
+		jobs:     jobs,
+		progress: progress,
+		opts:     opts,
+	}
+}
+
+// Start launches the workers, they keep running until Stop is called
+func (r *Runner) Start(ctx context.Context) {
+	intakeCtx, stopIntake := context.WithCancel(context.WithoutCancel(ctx))
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	r.stopIntake = stopIntake
+	r.cancelJobs = cancelJobs
+
+	for i := 0; i < r.opts.Workers; i++ {
+		r.wg.Add(1)
+		go r.work(intakeCtx, jobCtx)
+	}
+
+	errLib.Info.Printf("Assessment runner has started %d workers", r.opts.Workers)
+}
+
+// Stop stops taking new jobs and waits for the running ones until the context is done
+func (r *Runner) Stop(ctx context.Context) error {
+	if r.stopIntake == nil {
+		return nil
+	}
+
+	r.stopIntake()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		r.cancelJobs()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (r *Runner) work(intakeCtx, jobCtx context.Context) {
+	defer r.wg.Done()
+	// A stopped runner takes no more jobs, even when one is ready
+	for intakeCtx.Err() == nil {
+		job, err := r.queue.Dequeue(intakeCtx)
+		if err != nil {
+			if intakeCtx.Err() != nil {
+				return
+			}
+
+			errLib.Error.Println(err)
+			continue
+		}
+
+		r.process(jobCtx, job)
+	}
+}
+
+func (r *Runner) process(ctx context.Context, job *assessmentEntities.Job) {
+	// The job context could be cancelled before the end, the outcome still has to be stored
+	storeCtx := context.WithoutCancel(ctx)
+	jobCtx, cancel := context.WithTimeout(ctx, r.opts.JobTimeout)
+	defer cancel()
+
+	job.Stage = ""
+	job.SetStatus(assessmentEntities.JobRunning)
+	r.save(storeCtx, job)
+
+	if job.Owner != nil {
+		jobCtx = authEntities.NewContext(jobCtx, job.Owner)
+	}
+
+	jobCtx = assessmentEntities.WithProgress(jobCtx, func(stage assessmentEntities.Stage, detail string) {
+		job.Stage = stage
+		r.publish(storeCtx, job, detail)
+	})
+	taskResult, err := r.svc.AssessTask(jobCtx, job.Input)
+	if err == nil {
+		job.TaskResultID = taskResult.ID
+		job.Error = ""
+		job.SetStatus(assessmentEntities.JobSucceeded)
+		r.save(storeCtx, job)
+		if err = r.queue.Ack(storeCtx, job); err != nil {
+			errLib.Error.Println(err)
+		}
+
+		return
+	}
+
+	// The job only tells the domain error, the upstream details stay in the logs
+	errLib.Error.Println("Assessment job", job.ID, "failed:", err)
+	job.Error = jobError(err).Error()
+	if isPermanent(err) {
+		if err = r.queue.DeadLetter(storeCtx, job); err != nil {
+			errLib.Error.Println(err)
+		}
+
+		job.SetStatus(assessmentEntities.JobFailed)
+		r.save(storeCtx, job)
+		return
+	}
+
+	deadLettered, err := r.queue.Nack(storeCtx, job, r.retryDelay(job.Attempts))
+	if err != nil {
+		errLib.Error.Println(err)
+	}
+
+	if deadLettered {
+		job.SetStatus(assessmentEntities.JobFailed)
+	} else {
+		job.SetStatus(assessmentEntities.JobQueued)
+	}
+
+	r.save(storeCtx, job)
+}
+
+// save stores the job and broadcasts its status to the subscribers
+func (r *Runner) save(ctx context.Context, job *assessmentEntities.Job) {
+	if err := r.jobs.Save(ctx, job); err != nil {
+		errLib.Error.Println(err)
+	}
+
+	r.publish(ctx, job, job.Error)
+}
+
+func (r *Runner) publish(ctx context.Context, job *assessmentEntities.Job, detail string) {
+	if err := r.progress.Publish(ctx, job.NewProgressEvent(detail)); err != nil {
+		errLib.Error.Println(err)
+	}
+}
+
+// retryDelay doubles the backoff at every attempt of a job up to maxRetryBackoff
+func (r *Runner) retryDelay(attempts int) time.Duration {
+	delay := r.opts.RetryBackoff
+	for i := 1; i < attempts && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRetryBackoff)
+}
+
+// isPermanent reports whether the error fails the job again at every attempt
+func isPermanent(err error) bool {
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jobError returns the domain error which a failed job reports, any other error is reported as ErrInternal
+func jobError(err error) error {
+	for _, known := range jobErrors {
+		if errors.Is(err, known) {
+			return known
+		}
+	}
+
+	return errDomain.ErrInternal
+}
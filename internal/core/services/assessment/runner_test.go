@@ -0,0 +1,275 @@
+package assessment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/config"
+	"github.com/lk153/quizgame-ai-serving/internal/adapters/storage/memory"
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	taskResultEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+)
+
+const testRetryBackoff = 50 * time.Millisecond
+
+// stubService assesses the tasks with the given function
+type stubService struct {
+	ports.IAssessmentService
+	assess func(ctx context.Context, input assessmentEntities.InputTask) (*taskResultEntities.TaskResultEntity, error)
+	calls  atomic.Int32
+}
+
+func (s *stubService) AssessTask(
+	ctx context.Context, input assessmentEntities.InputTask,
+) (*taskResultEntities.TaskResultEntity, error) {
+	s.calls.Add(1)
+	return s.assess(ctx, input)
+}
+
+type testRunner struct {
+	*Runner
+	queue *memory.AssessmentQueue
+	jobs  *memory.AssessmentJobRepository
+}
+
+func newTestRunner(t *testing.T, svc ports.IAssessmentService, maxAttempts int) *testRunner {
+	t.Helper()
+	queue := memory.NewAssessmentQueue(&config.Assessment{MaxAttempts: maxAttempts})
+	jobs := memory.NewAssessmentJobRepository()
+	r := &testRunner{
+		Runner: NewRunner(svc, queue, jobs, memory.NewAssessmentProgress(), RunnerOptions{Workers: 1, JobTimeout: time.Second, RetryBackoff: testRetryBackoff}),
+		queue:  queue,
+		jobs:   jobs,
+	}
+
+	r.Start(context.Background())
+	t.Cleanup(func() { r.Stop(context.Background()) })
+	return r
+}
+
+func (r *testRunner) enqueue(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	job := assessmentEntities.NewJob(assessmentEntities.InputTask{CandidateText: "essay"})
+	if err := r.jobs.Save(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.queue.Enqueue(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	return job.ID
+}
+
+// waitJob waits until the stored job satisfies the condition
+func (r *testRunner) waitJob(t *testing.T, id string, done func(job *assessmentEntities.Job) bool) *assessmentEntities.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := r.jobs.GetByID(context.Background(), id)
+		if err == nil && done(job) {
+			return job
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("job = %+v, %v before the deadline", job, err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func isDone(job *assessmentEntities.Job) bool {
+	return job.IsDone()
+}
+
+func TestRunnerSucceeds(t *testing.T) {
+	svc := &stubService{assess: func(ctx context.Context, input assessmentEntities.InputTask) (*taskResultEntities.TaskResultEntity, error) {
+		assessmentEntities.ReportProgress(ctx, assessmentEntities.StageParsed, "")
+		return &taskResultEntities.TaskResultEntity{ID: "result-1"}, nil
+	}}
+	r := newTestRunner(t, svc, 3)
+
+	job := r.waitJob(t, r.enqueue(t), isDone)
+	if job.Status != assessmentEntities.JobSucceeded || job.TaskResultID != "result-1" || job.Error != "" {
+		t.Errorf("job = %+v, want succeeded with result-1", job)
+	}
+
+	if job.Attempts != 1 || job.Stage != assessmentEntities.StageParsed {
+		t.Errorf("job attempts = %d, stage = %q", job.Attempts, job.Stage)
+	}
+
+	if dead := r.queue.DeadLetters(); len(dead) != 0 {
+		t.Errorf("dead letters = %v", dead)
+	}
+}
+
+func TestRunnerRequeuesFailure(t *testing.T) {
+	svc := &stubService{}
+	svc.assess = func(ctx context.Context, input assessmentEntities.InputTask) (*taskResultEntities.TaskResultEntity, error) {
+		if svc.calls.Load() == 1 {
+			return nil, errors.New("connection reset")
+		}
+
+		return &taskResultEntities.TaskResultEntity{ID: "result-1"}, nil
+	}
+	r := newTestRunner(t, svc, 3)
+
+	job := r.waitJob(t, r.enqueue(t), isDone)
+	if job.Status != assessmentEntities.JobSucceeded || job.Attempts != 2 || svc.calls.Load() != 2 {
+		t.Errorf("job = %+v after %d calls, want succeeded at the second attempt", job, svc.calls.Load())
+	}
+}
+
+func TestRunnerDeadLetters(t *testing.T) {
+	svc := &stubService{assess: func(ctx context.Context, input assessmentEntities.InputTask) (*taskResultEntities.TaskResultEntity, error) {
+		return nil, errors.New("connection reset")
+	}}
+	r := newTestRunner(t, svc, 3)
+
+	job := r.waitJob(t, r.enqueue(t), isDone)
+	if job.Status != assessmentEntities.JobFailed || job.Attempts != 3 || svc.calls.Load() != 3 {
+		t.Errorf("job = %+v after %d calls, want failed after 3 attempts", job, svc.calls.Load())
+	}
+
+	// The upstream error stays in the logs
+	if job.Error != errDomain.ErrInternal.Error() {
+		t.Errorf("job error = %q, want %q", job.Error, errDomain.ErrInternal)
+	}
+
+	if dead := r.queue.DeadLetters(); len(dead) != 1 || dead[0].ID != job.ID {
+		t.Errorf("dead letters = %v, want the job", dead)
+	}
+}
+
+func TestRunnerDeadLettersPermanentErrors(t *testing.T) {
+	for _, permanent := range permanentErrors {
+		t.Run(permanent.Error(), func(t *testing.T) {
+			svc := &stubService{assess: func(ctx context.Context, input assessmentEntities.InputTask) (*taskResultEntities.TaskResultEntity, error) {
+				return nil, fmt.Errorf("%w: upstream details", permanent)
+			}}
+			r := newTestRunner(t, svc, 3)
+
+			job := r.waitJob(t, r.enqueue(t), isDone)
+			if job.Status != assessmentEntities.JobFailed || job.Attempts != 1 || svc.calls.Load() != 1 {
+				t.Errorf("job = %+v after %d calls, want failed at the first attempt", job, svc.calls.Load())
+			}
+
+			if job.Error != permanent.Error() {
+				t.Errorf("job error = %q, want %q", job.Error, permanent)
+			}
+
+			if dead := r.queue.DeadLetters(); len(dead) != 1 {
+				t.Errorf("dead letters = %v, want the job", dead)
+			}
+		})
+	}
+}
+
+func TestRunnerBacksOff(t *testing.T) {
+	var firstCall time.Time
+	svc := &stubService{}
+	svc.assess = func(ctx context.Context, input assessmentEntities.InputTask) (*taskResultEntities.TaskResultEntity, error) {
+		if svc.calls.Load() == 1 {
+			firstCall = time.Now()
+			return nil, errDomain.ErrAssessorRateLimited
+		}
+
+		if elapsed := time.Since(firstCall); elapsed < testRetryBackoff {
+			t.Errorf("job is retried after %s, want the backoff of %s", elapsed, testRetryBackoff)
+		}
+
+		return &taskResultEntities.TaskResultEntity{ID: "result-1"}, nil
+	}
+	r := newTestRunner(t, svc, 3)
+
+	job := r.waitJob(t, r.enqueue(t), isDone)
+	if job.Status != assessmentEntities.JobSucceeded || svc.calls.Load() != 2 {
+		t.Errorf("job = %+v after %d calls, want succeeded at the second attempt", job, svc.calls.Load())
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	r := NewRunner(nil, nil, nil, nil, RunnerOptions{RetryBackoff: time.Minute})
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 3, want: 4 * time.Minute},
+		{attempts: 5, want: maxRetryBackoff},
+		{attempts: 100, want: maxRetryBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := r.retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRunnerStopDrains(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	svc := &stubService{assess: func(ctx context.Context, input assessmentEntities.InputTask) (*taskResultEntities.TaskResultEntity, error) {
+		close(started)
+		<-release
+		return &taskResultEntities.TaskResultEntity{ID: "result-1"}, ctx.Err()
+	}}
+	r := newTestRunner(t, svc, 3)
+
+	id := r.enqueue(t)
+	<-started
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- r.Stop(context.Background()) }()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("Stop() = %v while a job is running", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A job queued after Stop is not taken
+	pending := r.enqueue(t)
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	if job := r.waitJob(t, id, isDone); job.Status != assessmentEntities.JobSucceeded {
+		t.Errorf("running job = %+v, want it finished", job)
+	}
+
+	if job, _ := r.jobs.GetByID(context.Background(), pending); job.Status != assessmentEntities.JobQueued {
+		t.Errorf("queued job = %+v, want it left in the queue", job)
+	}
+}
+
+func TestRunnerStopTimeout(t *testing.T) {
+	started := make(chan struct{})
+	svc := &stubService{assess: func(ctx context.Context, input assessmentEntities.InputTask) (*taskResultEntities.TaskResultEntity, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	r := newTestRunner(t, svc, 3)
+
+	r.enqueue(t)
+	<-started
+
+	// The running job is cancelled once the grace period is over
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := r.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,435 @@
+package assessment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	authEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/auth"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/internal/core/domains/rubric"
+	taskResultEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	cacheLib "github.com/lk153/quizgame-ai-serving/lib/cache"
+	errLib "github.com/lk153/quizgame-ai-serving/lib/errors"
+	"github.com/lk153/quizgame-ai-serving/lib/essaymetrics"
+)
+
+var (
+	_           ports.IAssessmentService = &AssessmentService{}
+	cachePrefix                          = "assessment"
+)
+
+// DefaultCacheTTL is how long an assessment is reused for the same content when no TTL is configured
+const DefaultCacheTTL = 24 * time.Hour
+
+// Options contains the settings of the assessment service
+type Options struct {
+	// CacheTTL is how long an assessment is reused for the same content, a negative TTL disables the cache
+	CacheTTL time.Duration
+}
+
+type AssessmentService struct {
+	assessor      ports.IAssessor
+	taskResultSvc ports.ITaskResultService
+	queue         ports.IAssessmentQueue
+	jobs          ports.IAssessmentJobRepository
+	progress      ports.IAssessmentProgress
+	cache         ports.ICacheRepository
+	policy        ports.IAssessmentPolicy
+	opts          Options
+}
+
+func NewAssessmentService(
+	assessor ports.IAssessor,
+	taskResultSvc ports.ITaskResultService,
+	queue ports.IAssessmentQueue,
+	jobs ports.IAssessmentJobRepository,
+	progress ports.IAssessmentProgress,
+	cache ports.ICacheRepository,
+	policy ports.IAssessmentPolicy,
+	opts Options,
+) *AssessmentService {
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+
+	return &AssessmentService{
+		assessor,
+		taskResultSvc,
+		queue,
+		jobs,
+		progress,
+		cache,
+		policy,
+		opts,
+	}
+}
+
+// AssessTask: assess a candidate task with the AI assessor and store its result
+func (a *AssessmentService) AssessTask(
+	ctx context.Context, input assessmentEntities.InputTask,
+) (task *taskResultEntities.TaskResultEntity, err error) {
+	// The caller is checked before the assessor is paid for
+	if err = a.policy.CanAssess(ctx); err != nil {
+		return
+	}
+
+	rb, err := input.ResolveRubric()
+	if err != nil {
+		return
+	}
+
+	metrics := essaymetrics.Compute(input.CandidateText, rb.MinWords)
+	result, cacheHit, err := a.assess(ctx, input, rb)
+	if err != nil {
+		return
+	}
+
+	result.Metrics = metrics
+	result.ApplyLengthPenalty(metrics, rb)
+	assessmentEntities.ReportProgress(ctx, assessmentEntities.StageParsed, "")
+	taskResult := newTaskResult(input, result, rb)
+	taskResult.CacheHit = cacheHit
+	setEnsemble(taskResult, result.Ensemble)
+	task, err = a.taskResultSvc.SubmitTask(ctx, taskResult)
+	if err != nil {
+		return
+	}
+
+	assessmentEntities.ReportProgress(ctx, assessmentEntities.StageStored, task.ID)
+	return
+}
+
+// AssessSpeaking: assess a Speaking part from its transcript with the AI assessor and store its result
+func (a *AssessmentService) AssessSpeaking(
+	ctx context.Context, input assessmentEntities.SpeakingInput,
+) (task *taskResultEntities.TaskResultEntity, err error) {
+	if err = a.policy.CanAssess(ctx); err != nil {
+		return
+	}
+
+	rb, err := rubric.Get(rubric.IELTSSpeaking)
+	if err != nil {
+		return
+	}
+
+	result, err := a.assessor.AssessSpeaking(ctx, input)
+	if err != nil {
+		err = assessorError(err)
+		return
+	}
+
+	if err = checkResult(&result.Result, rb); err != nil {
+		return
+	}
+
+	assessmentEntities.ReportProgress(ctx, assessmentEntities.StageParsed, "")
+	taskResult := newSpeakingTaskResult(input, result, rb)
+	setEnsemble(taskResult, result.Ensemble)
+	task, err = a.taskResultSvc.SubmitTask(ctx, taskResult)
+	if err != nil {
+		return
+	}
+
+	assessmentEntities.ReportProgress(ctx, assessmentEntities.StageStored, task.ID)
+	return
+}
+
+// MeasureTask: compute the metrics of a candidate text without the AI assessor
+func (a *AssessmentService) MeasureTask(
+	ctx context.Context, input assessmentEntities.InputTask,
+) (*essaymetrics.Metrics, error) {
+	rb, err := input.ResolveRubric()
+	if err != nil {
+		return nil, err
+	}
+
+	return essaymetrics.Compute(input.CandidateText, rb.MinWords), nil
+}
+
+// EnqueueTask: queue a candidate task to be assessed by the background workers
+func (a *AssessmentService) EnqueueTask(
+	ctx context.Context, input assessmentEntities.InputTask,
+) (job *assessmentEntities.Job, err error) {
+	// A caller who may not assess and an unknown rubric are rejected now rather than by the background workers
+	if err = a.policy.CanAssess(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err = input.ResolveRubric(); err != nil {
+		return nil, err
+	}
+
+	job = assessmentEntities.NewJob(input)
+	job.Owner = authEntities.FromContext(ctx)
+	if err = a.jobs.Save(ctx, job); err != nil {
+		errLib.Error.Println(err)
+		return nil, errDomain.ErrInternal
+	}
+
+	if err = a.queue.Enqueue(ctx, job); err != nil {
+		errLib.Error.Println(err)
+		if err == errDomain.ErrQueueFull {
+			return nil, err
+		}
+
+		return nil, errDomain.ErrInternal
+	}
+
+	return
+}
+
+// GetJob: return an assessment job by id, to its owner, the teachers of the owner's classes and the admins
+func (a *AssessmentService) GetJob(ctx context.Context, id string) (job *assessmentEntities.Job, err error) {
+	job, err = a.jobs.GetByID(ctx, id)
+	if err != nil {
+		if err == errDomain.ErrDataNotFound {
+			return
+		}
+
+		errLib.Error.Println(err)
+		return nil, errDomain.ErrInternal
+	}
+
+	if err = a.policy.CanReadJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// WatchJob: stream the progress of an assessment job until it is done, the job is checked as by GetJob
+func (a *AssessmentService) WatchJob(
+	ctx context.Context, id string,
+) (<-chan assessmentEntities.ProgressEvent, error) {
+	// Subscribe before reading the job so that no status change happens unseen in between
+	subCtx, cancel := context.WithCancel(ctx)
+	events, err := a.progress.Subscribe(subCtx, id)
+	if err != nil {
+		cancel()
+		errLib.Error.Println(err)
+		return nil, errDomain.ErrInternal
+	}
+
+	job, err := a.GetJob(ctx, id)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	out := make(chan assessmentEntities.ProgressEvent, 1)
+	out <- job.NewProgressEvent("")
+	if job.IsDone() {
+		cancel()
+		close(out)
+		return out, nil
+	}
+
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		for event := range events {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+
+			if event.IsDone() {
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// assess returns the assessment of the task, it is reused when the same content was assessed before.
+// The assessment is cached as the assessor returned it, so that a cached one is normalised the same way.
+// A reused assessment spends no token, its usage is zero
+func (a *AssessmentService) assess(
+	ctx context.Context, input assessmentEntities.InputTask, rb *rubric.Rubric,
+) (result *assessmentEntities.Result, cacheHit bool, err error) {
+	promptVersion := a.assessor.PromptVersion(input.PromptVersion)
+	cacheKey := cacheLib.GenerateCacheKey(cachePrefix, input.ContentHash(rb, promptVersion))
+	if !input.ForceRefresh {
+		// A cached assessment which no longer fits the rubric is assessed again
+		if result = a.cachedResult(ctx, cacheKey); result != nil && checkResult(result, rb) == nil {
+			result.Usage = assessmentEntities.TokenUsage{}
+			return result, true, nil
+		}
+	}
+
+	result, err = a.assessor.Assess(ctx, input)
+	if err != nil {
+		err = assessorError(err)
+		return
+	}
+
+	raw, serializeErr := cacheLib.Serialize(result)
+	if err = checkResult(result, rb); err != nil {
+		return
+	}
+
+	if serializeErr != nil {
+		errLib.Error.Println(serializeErr)
+		return
+	}
+
+	a.cacheResult(ctx, cacheKey, raw)
+	return
+}
+
+// cachedResult returns the cached assessment, nil when there is none
+func (a *AssessmentService) cachedResult(ctx context.Context, cacheKey string) *assessmentEntities.Result {
+	if a.cache == nil || a.opts.CacheTTL < 0 {
+		return nil
+	}
+
+	cached, err := a.cache.Get(ctx, cacheKey)
+	if err != nil {
+		return nil
+	}
+
+	var result *assessmentEntities.Result
+	if err = cacheLib.Deserialize(cached, &result); err != nil {
+		errLib.Error.Println(err)
+		return nil
+	}
+
+	return result
+}
+
+// cacheResult stores the assessment, an assessment is not failed because the cache is
+func (a *AssessmentService) cacheResult(ctx context.Context, cacheKey string, raw []byte) {
+	if a.cache == nil || a.opts.CacheTTL < 0 {
+		return
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, raw, a.opts.CacheTTL); err != nil {
+		errLib.Error.Println(err)
+	}
+}
+
+// assessorError logs the error of the assessor and keeps the domain errors it returns
+func assessorError(err error) error {
+	errLib.Error.Println(err)
+	if err == errDomain.ErrUnparsableAssessment || err == errDomain.ErrUnknownPromptVersion ||
+		err == errDomain.ErrUnknownRubric {
+		return err
+	}
+
+	// The cause is kept, it could be tagged with a domain error by the assessor adapter
+	return fmt.Errorf("%w: %w", errDomain.ErrInternal, err)
+}
+
+// checkResult normalises the scores of an assessment and rejects it when they are out of the scale of the rubric
+func checkResult(result *assessmentEntities.Result, rb *rubric.Rubric) error {
+	result.Normalise(rb)
+	if result.OverallMismatch {
+		errLib.Warn.Printf("Overall band %v of the assessor disagrees with its criteria %v",
+			result.ModelOverallScore, result.OverallScore)
+	}
+
+	if result.Ensemble != nil && result.Ensemble.NeedsReview {
+		errLib.Warn.Printf("Assessment needs a human review, the %d runs spread over %v",
+			result.Ensemble.Runs, result.Ensemble.Spread)
+	}
+
+	if _, err := result.Validate(rb); err != nil {
+		errLib.Error.Println(err)
+		return errDomain.ErrUnparsableAssessment
+	}
+
+	return nil
+}
+
+// newTaskResult builds the task result which is stored for an assessment
+func newTaskResult(
+	input assessmentEntities.InputTask, result *assessmentEntities.Result, rb *rubric.Rubric,
+) *taskResultEntities.TaskResultEntity {
+	return &taskResultEntities.TaskResultEntity{
+		ID:               uuid.NewString(),
+		Name:             rb.Title,
+		Exam:             rb.Exam,
+		Rubric:           rb.ID,
+		Score:            result.OverallScore,
+		Level:            rb.Label(result.OverallScore),
+		TaskType:         input.TaskType,
+		TaskRequirement:  input.TaskRequirement,
+		CandidateText:    input.CandidateText,
+		Criteria:         newCriterionScores(result.Details),
+		SuggestEssay:     result.SuggestEssay,
+		Model:            result.Model,
+		PromptVersion:    result.PromptVersion,
+		Flags:            result.Flags,
+		Suspicious:       result.Suspicious,
+		Metrics:          result.Metrics,
+		ModelScore:       result.ModelOverallScore,
+		ScoreMismatch:    result.OverallMismatch,
+		PromptTokens:     result.Usage.PromptTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+	}
+}
+
+// newSpeakingTaskResult builds the task result which is stored for the assessment of a Speaking part,
+// the prompts are stored as the requirement and the transcript as the candidate text
+func newSpeakingTaskResult(
+	input assessmentEntities.SpeakingInput, result *assessmentEntities.SpeakingResult, rb *rubric.Rubric,
+) *taskResultEntities.TaskResultEntity {
+	return &taskResultEntities.TaskResultEntity{
+		ID:               uuid.NewString(),
+		Name:             fmt.Sprintf("%s Part %d", rb.Title, input.Part),
+		Exam:             rb.Exam,
+		Rubric:           rb.ID,
+		Score:            result.OverallScore,
+		TaskType:         input.Part,
+		TaskRequirement:  strings.Join(input.Prompts, "\n"),
+		CandidateText:    input.TranscriptText(),
+		Criteria:         newCriterionScores(result.Details),
+		SuggestEssay:     result.SuggestEssay,
+		Model:            result.Model,
+		PromptVersion:    result.PromptVersion,
+		Flags:            result.Flags,
+		Suspicious:       result.Suspicious,
+		ModelScore:       result.ModelOverallScore,
+		ScoreMismatch:    result.OverallMismatch,
+		Pronunciation:    string(result.Pronunciation),
+		PromptTokens:     result.Usage.PromptTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+	}
+}
+
+func newCriterionScores(details []assessmentEntities.Criterion) []taskResultEntities.CriterionScore {
+	criteria := make([]taskResultEntities.CriterionScore, 0, len(details))
+	for _, c := range details {
+		criteria = append(criteria, taskResultEntities.CriterionScore{
+			Name:         c.Name,
+			BandScore:    c.BandScore,
+			HowToImprove: c.HowToImprove,
+			Strengths:    c.Strengths,
+			Penalty:      c.Penalty,
+			Spread:       c.Spread,
+		})
+	}
+
+	return criteria
+}
+
+// setEnsemble records how the score was combined, a result of a single run has no spread
+func setEnsemble(task *taskResultEntities.TaskResultEntity, ensemble *assessmentEntities.Ensemble) {
+	task.Runs = 1
+	if ensemble == nil {
+		return
+	}
+
+	task.Runs = ensemble.Runs
+	task.Spread = ensemble.Spread
+	task.NeedsReview = ensemble.NeedsReview
+}
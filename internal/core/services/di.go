@@ -4,10 +4,21 @@ import (
 	"github.com/google/wire"
 
 	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+	assessmentSvc "github.com/lk153/quizgame-ai-serving/internal/core/services/assessment"
+	"github.com/lk153/quizgame-ai-serving/internal/core/services/policy"
 	taskResultSvc "github.com/lk153/quizgame-ai-serving/internal/core/services/taskResult"
 )
 
 var ServiceSet = wire.NewSet(
+	policy.NewTaskResultPolicy,
+	wire.Bind(new(ports.ITaskResultPolicy), new(*policy.TaskResultPolicy)),
+	policy.NewAssessmentPolicy,
+	wire.Bind(new(ports.IAssessmentPolicy), new(*policy.AssessmentPolicy)),
+
 	taskResultSvc.NewTaskResultService,
 	wire.Bind(new(ports.ITaskResultService), new(*taskResultSvc.TaskResultService)),
+
+	assessmentSvc.NewAssessmentService,
+	wire.Bind(new(ports.IAssessmentService), new(*assessmentSvc.AssessmentService)),
+	assessmentSvc.NewRunner,
 )
@@ -0,0 +1,48 @@
+package policy
+
+import (
+	"context"
+
+	assessmentEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/assessment"
+	authEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/auth"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+)
+
+var _ ports.IAssessmentPolicy = &AssessmentPolicy{}
+
+// AssessmentPolicy lets the callers who may submit a task result have a task assessed,
+// and the owner of a job, the teachers of the owner's classes and the admins follow the job
+type AssessmentPolicy struct {
+	taskResult *TaskResultPolicy
+}
+
+func NewAssessmentPolicy(taskResult *TaskResultPolicy) *AssessmentPolicy {
+	return &AssessmentPolicy{taskResult}
+}
+
+// CanAssess: an assessment stores a task result, so that it is allowed as the task result is
+func (p *AssessmentPolicy) CanAssess(ctx context.Context) error {
+	return p.taskResult.CanCreate(ctx)
+}
+
+// CanReadJob: the owner, the teachers of the owner's classes and the admins see a job
+func (p *AssessmentPolicy) CanReadJob(ctx context.Context, job *assessmentEntities.Job) error {
+	principal, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case principal.HasRole(authEntities.RoleAdmin):
+		return nil
+	case job.Owner == nil:
+		// A job queued without an owner is only seen by the admins
+	case principal.HasRole(authEntities.RoleStudent, authEntities.RoleTeacher) && job.Owner.Subject == principal.Subject:
+		return nil
+	case principal.HasRole(authEntities.RoleTeacher) && teaches(principal, job.Owner.Classes):
+		return nil
+	}
+
+	return errDomain.ErrForbidden
+}
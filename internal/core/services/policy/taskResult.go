@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"context"
+	"slices"
+
+	authEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/auth"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	taskResultEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
+	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
+)
+
+var _ ports.ITaskResultPolicy = &TaskResultPolicy{}
+
+// TaskResultPolicy lets a student see and submit their own task results,
+// a teacher see and update the task results of their classes, and an admin do everything
+type TaskResultPolicy struct{}
+
+func NewTaskResultPolicy() *TaskResultPolicy {
+	return &TaskResultPolicy{}
+}
+
+// CanCreate: every known role may submit a task result
+func (p *TaskResultPolicy) CanCreate(ctx context.Context) error {
+	principal, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !principal.HasRole(authEntities.RoleStudent, authEntities.RoleTeacher, authEntities.RoleAdmin) {
+		return errDomain.ErrForbidden
+	}
+
+	return nil
+}
+
+// CanRead: the owner, the teachers of its classes and the admins see a task result
+func (p *TaskResultPolicy) CanRead(ctx context.Context, task *taskResultEntities.TaskResultEntity) error {
+	principal, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case principal.HasRole(authEntities.RoleAdmin):
+		return nil
+	case principal.HasRole(authEntities.RoleStudent, authEntities.RoleTeacher) && task.OwnerID == principal.Subject:
+		return nil
+	case principal.HasRole(authEntities.RoleTeacher) && teaches(principal, task.ClassIDs):
+		return nil
+	}
+
+	return errDomain.ErrForbidden
+}
+
+// CanUpdate: the teachers of its classes and the admins change a task result, e.g. to comment it
+func (p *TaskResultPolicy) CanUpdate(ctx context.Context, task *taskResultEntities.TaskResultEntity) error {
+	principal, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case principal.HasRole(authEntities.RoleAdmin):
+		return nil
+	case principal.HasRole(authEntities.RoleTeacher) && (teaches(principal, task.ClassIDs) || task.OwnerID == principal.Subject):
+		return nil
+	}
+
+	return errDomain.ErrForbidden
+}
+
+// CanDelete: only the admins delete a task result
+func (p *TaskResultPolicy) CanDelete(ctx context.Context, _ *taskResultEntities.TaskResultEntity) error {
+	principal, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !principal.HasRole(authEntities.RoleAdmin) {
+		return errDomain.ErrForbidden
+	}
+
+	return nil
+}
+
+// ListFilter: an admin lists every task result, a teacher their own and the ones of their classes,
+// a student their own
+func (p *TaskResultPolicy) ListFilter(ctx context.Context) (taskResultEntities.ListFilter, error) {
+	principal, err := caller(ctx)
+	if err != nil {
+		return taskResultEntities.ListFilter{}, err
+	}
+
+	switch {
+	case principal.HasRole(authEntities.RoleAdmin):
+		return taskResultEntities.ListFilter{}, nil
+	case principal.HasRole(authEntities.RoleTeacher):
+		return taskResultEntities.ListFilter{OwnerID: principal.Subject, ClassIDs: principal.Classes}, nil
+	case principal.HasRole(authEntities.RoleStudent):
+		return taskResultEntities.ListFilter{OwnerID: principal.Subject}, nil
+	}
+
+	return taskResultEntities.ListFilter{}, errDomain.ErrForbidden
+}
+
+// caller returns the authenticated caller of the context
+func caller(ctx context.Context) (*authEntities.Principal, error) {
+	principal := authEntities.FromContext(ctx)
+	if principal == nil || principal.Subject == "" {
+		return nil, errDomain.ErrUnauthorized
+	}
+
+	return principal, nil
+}
+
+// teaches tells one of the classes is a class of the principal
+func teaches(principal *authEntities.Principal, classes []string) bool {
+	for _, class := range classes {
+		if slices.Contains(principal.Classes, class) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	authEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/auth"
+	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
+	taskResultEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
+)
+
+// testTask is owned by student-1 who attends the class c1
+var testTask = &taskResultEntities.TaskResultEntity{ID: "result-1", OwnerID: "student-1", ClassIDs: []string{"c1"}}
+
+func TestTaskResultPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *authEntities.Principal
+		wantCreate error
+		wantRead   error
+		wantUpdate error
+		wantDelete error
+		wantFilter taskResultEntities.ListFilter
+		wantList   error
+	}{
+		{
+			name:       "student/own",
+			principal:  &authEntities.Principal{Subject: "student-1", Roles: []string{authEntities.RoleStudent}, Classes: []string{"c1"}},
+			wantUpdate: errDomain.ErrForbidden,
+			wantDelete: errDomain.ErrForbidden,
+			wantFilter: taskResultEntities.ListFilter{OwnerID: "student-1"},
+		},
+		{
+			name:       "student/other",
+			principal:  &authEntities.Principal{Subject: "student-2", Roles: []string{authEntities.RoleStudent}, Classes: []string{"c1"}},
+			wantRead:   errDomain.ErrForbidden,
+			wantUpdate: errDomain.ErrForbidden,
+			wantDelete: errDomain.ErrForbidden,
+			wantFilter: taskResultEntities.ListFilter{OwnerID: "student-2"},
+		},
+		{
+			name:       "teacher/class",
+			principal:  &authEntities.Principal{Subject: "teacher-1", Roles: []string{authEntities.RoleTeacher}, Classes: []string{"c2", "c1"}},
+			wantDelete: errDomain.ErrForbidden,
+			wantFilter: taskResultEntities.ListFilter{OwnerID: "teacher-1", ClassIDs: []string{"c2", "c1"}},
+		},
+		{
+			name:       "teacher/other",
+			principal:  &authEntities.Principal{Subject: "teacher-2", Roles: []string{authEntities.RoleTeacher}, Classes: []string{"c2"}},
+			wantRead:   errDomain.ErrForbidden,
+			wantUpdate: errDomain.ErrForbidden,
+			wantDelete: errDomain.ErrForbidden,
+			wantFilter: taskResultEntities.ListFilter{OwnerID: "teacher-2", ClassIDs: []string{"c2"}},
+		},
+		{
+			name:      "admin",
+			principal: &authEntities.Principal{Subject: "admin-1", Roles: []string{authEntities.RoleAdmin}},
+		},
+		{
+			name:       "unknown role",
+			principal:  &authEntities.Principal{Subject: "guest-1", Roles: []string{"guest"}},
+			wantCreate: errDomain.ErrForbidden,
+			wantRead:   errDomain.ErrForbidden,
+			wantUpdate: errDomain.ErrForbidden,
+			wantDelete: errDomain.ErrForbidden,
+			wantList:   errDomain.ErrForbidden,
+		},
+		{
+			name:       "missing principal",
+			wantCreate: errDomain.ErrUnauthorized,
+			wantRead:   errDomain.ErrUnauthorized,
+			wantUpdate: errDomain.ErrUnauthorized,
+			wantDelete: errDomain.ErrUnauthorized,
+			wantList:   errDomain.ErrUnauthorized,
+		},
+		{
+			name:       "principal without subject",
+			principal:  &authEntities.Principal{Roles: []string{authEntities.RoleAdmin}},
+			wantCreate: errDomain.ErrUnauthorized,
+			wantRead:   errDomain.ErrUnauthorized,
+			wantUpdate: errDomain.ErrUnauthorized,
+			wantDelete: errDomain.ErrUnauthorized,
+			wantList:   errDomain.ErrUnauthorized,
+		},
+	}
+
+	p := NewTaskResultPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = authEntities.NewContext(ctx, tt.principal)
+			}
+
+			if err := p.CanCreate(ctx); !errors.Is(err, tt.wantCreate) || (tt.wantCreate == nil && err != nil) {
+				t.Errorf("CanCreate() = %v, want %v", err, tt.wantCreate)
+			}
+
+			if err := p.CanRead(ctx, testTask); !errors.Is(err, tt.wantRead) || (tt.wantRead == nil && err != nil) {
+				t.Errorf("CanRead() = %v, want %v", err, tt.wantRead)
+			}
+
+			if err := p.CanUpdate(ctx, testTask); !errors.Is(err, tt.wantUpdate) || (tt.wantUpdate == nil && err != nil) {
+				t.Errorf("CanUpdate() = %v, want %v", err, tt.wantUpdate)
+			}
+
+			if err := p.CanDelete(ctx, testTask); !errors.Is(err, tt.wantDelete) || (tt.wantDelete == nil && err != nil) {
+				t.Errorf("CanDelete() = %v, want %v", err, tt.wantDelete)
+			}
+
+			filter, err := p.ListFilter(ctx)
+			if !errors.Is(err, tt.wantList) || (tt.wantList == nil && err != nil) {
+				t.Errorf("ListFilter() error = %v, want %v", err, tt.wantList)
+			}
+
+			if !reflect.DeepEqual(filter, tt.wantFilter) {
+				t.Errorf("ListFilter() = %+v, want %+v", filter, tt.wantFilter)
+			}
+		})
+	}
+}
+
+func TestTaskResultPolicyTeacherOwnTask(t *testing.T) {
+	// A teacher who submitted a task result of their own changes it even outside their classes
+	task := &taskResultEntities.TaskResultEntity{OwnerID: "teacher-1"}
+	ctx := authEntities.NewContext(context.Background(),
+		&authEntities.Principal{Subject: "teacher-1", Roles: []string{authEntities.RoleTeacher}})
+
+	p := NewTaskResultPolicy()
+	if err := p.CanRead(ctx, task); err != nil {
+		t.Errorf("CanRead() = %v, want nil", err)
+	}
+
+	if err := p.CanUpdate(ctx, task); err != nil {
+		t.Errorf("CanUpdate() = %v, want nil", err)
+	}
+}
@@ -3,7 +3,10 @@ package taskresult
 import (
 	"context"
 	"log"
+	"strings"
+	"time"
 
+	authEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/auth"
 	errDomain "github.com/lk153/quizgame-ai-serving/internal/core/domains/error"
 	taskResultEntities "github.com/lk153/quizgame-ai-serving/internal/core/domains/taskResult"
 	"github.com/lk153/quizgame-ai-serving/internal/core/ports"
@@ -18,14 +21,18 @@ var (
 )
 
 type TaskResultService struct {
-	repo  ports.ITaskResultRepository
-	cache ports.ICacheRepository
+	repo   ports.ITaskResultRepository
+	cache  ports.ICacheRepository
+	policy ports.ITaskResultPolicy
 }
 
-func NewTaskResultService(repo ports.ITaskResultRepository, cache ports.ICacheRepository) *TaskResultService {
+func NewTaskResultService(
+	repo ports.ITaskResultRepository, cache ports.ICacheRepository, policy ports.ITaskResultPolicy,
+) *TaskResultService {
 	return &TaskResultService{
 		repo,
 		cache,
+		policy,
 	}
 }
 
@@ -38,6 +45,17 @@ func (u *TaskResultService) SubmitTask(
 		taskSerialized []byte
 	)
 
+	if err = u.policy.CanCreate(ctx); err != nil {
+		return
+	}
+
+	// The task result is owned by the caller and visible to the teachers of the caller's classes
+	principal := authEntities.FromContext(ctx)
+	task.OwnerID = principal.Subject
+	task.ClassIDs = principal.Classes
+	now := time.Now().UTC()
+	task.CreatedAt = now
+	task.UpdatedAt = now
 	task, err = u.repo.Create(ctx, task)
 	if err != nil {
 		errLib.Error.Println(err)
@@ -63,6 +81,7 @@ func (u *TaskResultService) SubmitTask(
 		goto ERR
 	}
 
+	e = task
 	return
 
 ERR:
@@ -89,6 +108,13 @@ func (u *TaskResultService) GetTaskResult(
 		err = cacheLib.Deserialize(cachedTask, &e)
 		if err != nil {
 			err = errDomain.ErrInternal
+			return
+		}
+
+		if e != nil {
+			if err = u.policy.CanRead(ctx, e); err != nil {
+				return nil, err
+			}
 		}
 
 		return
@@ -105,6 +131,12 @@ GETDB:
 		return
 	}
 
+	if e != nil {
+		if err = u.policy.CanRead(ctx, e); err != nil {
+			return nil, err
+		}
+	}
+
 	taskSerialized, err := cacheLib.Serialize(e)
 	if err != nil {
 		err = errDomain.ErrInternal
@@ -126,12 +158,18 @@ func (u *TaskResultService) ListTaskResults(
 	var (
 		params, cacheKey string
 		cachedTasks      []byte
+		filter           taskResultEntities.ListFilter
 	)
+	filter, err = u.policy.ListFilter(ctx)
+	if err != nil {
+		return
+	}
+
 	if u.cache == nil {
 		goto GETDB
 	}
 
-	params = cacheLib.GenerateCacheKeyParams(skip, limit)
+	params = cacheLib.GenerateCacheKeyParams(filter.OwnerID, strings.Join(filter.ClassIDs, ","), skip, limit)
 	cacheKey = cacheLib.GenerateCacheKey(cacheListPrefix, params)
 	cachedTasks, err = u.cache.Get(ctx, cacheKey)
 	if err == nil {
@@ -145,7 +183,7 @@ func (u *TaskResultService) ListTaskResults(
 	}
 
 GETDB:
-	tasks, err = u.repo.List(ctx, skip, limit)
+	tasks, err = u.repo.List(ctx, filter, skip, limit)
 	if err != nil {
 		log.Println("ERR:", err)
 		err = errDomain.ErrInternal
@@ -180,20 +218,32 @@ func (u *TaskResultService) UpdateTaskResult(
 		return nil, errDomain.ErrInternal
 	}
 
+	if existingTask == nil {
+		return nil, errDomain.ErrDataNotFound
+	}
+
+	if err = u.policy.CanUpdate(ctx, existingTask); err != nil {
+		return nil, err
+	}
+
+	// The ownership is kept whatever the update carries
+	task.OwnerID = existingTask.OwnerID
+	task.ClassIDs = existingTask.ClassIDs
 	emptyData := task.Name == ""
 	sameData := existingTask.Name == task.Name
 	if emptyData || sameData {
 		return nil, errDomain.ErrNoUpdatedData
 	}
 
-	_, err = u.repo.Update(ctx, task)
+	task.UpdatedAt = time.Now().UTC()
+
+	e, err = u.repo.Update(ctx, task)
 	if err != nil {
-		if err == errDomain.ErrConflictingData {
-			return
+		if err == errDomain.ErrConflictingData || err == errDomain.ErrDataNotFound {
+			return nil, err
 		}
 
-		err = errDomain.ErrInternal
-		return
+		return nil, errDomain.ErrInternal
 	}
 
 	cacheKey := cacheLib.GenerateCacheKey(cachePrefix, task.ID)
@@ -202,7 +252,7 @@ func (u *TaskResultService) UpdateTaskResult(
 		return
 	}
 
-	taskSerialized, err := cacheLib.Serialize(task)
+	taskSerialized, err := cacheLib.Serialize(e)
 	if err != nil {
 		err = errDomain.ErrInternal
 		return
@@ -222,7 +272,7 @@ func (u *TaskResultService) UpdateTaskResult(
 
 // DeleteTaskResult: delete a task result
 func (u *TaskResultService) DeleteTaskResult(ctx context.Context, id string) (err error) {
-	_, err = u.repo.GetByID(ctx, id)
+	existingTask, err := u.repo.GetByID(ctx, id)
 	if err != nil {
 		if err == errDomain.ErrDataNotFound {
 			return
@@ -231,6 +281,14 @@ func (u *TaskResultService) DeleteTaskResult(ctx context.Context, id string) (er
 		return errDomain.ErrInternal
 	}
 
+	if existingTask == nil {
+		return errDomain.ErrDataNotFound
+	}
+
+	if err = u.policy.CanDelete(ctx, existingTask); err != nil {
+		return
+	}
+
 	cacheKey := cacheLib.GenerateCacheKey(cachePrefix, id)
 	if err = u.cache.Delete(ctx, cacheKey); err != nil {
 		return errDomain.ErrInternal
@@ -3,172 +3,247 @@ package copilotAgent
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/openai"
 )
 
+const (
+	// ModelName identifies the AI model which produces the assessment
+	ModelName = "copilot-directline"
+
+	// maxPollInterval bounds the doubling wait between two ReceiveMessages calls
+	maxPollInterval = 8 * time.Second
+	// maxRephrase bounds how many times the bot is asked again after it can not understand the prompt
+	maxRephrase = 3
+
+	rephraseReply = "I'm sorry, I'm not sure how to help with that. Can you try rephrasing?"
+)
+
+// Progress stages reported while an assessment is running
+const (
+	ProgressSentToBot    = "sent_to_bot"
+	ProgressWaiting      = "waiting"
+	ProgressPartialReply = "partial_reply"
+)
+
+// ProgressFunc receives the stages of an assessment
+type ProgressFunc func(stage string, detail string)
+
 type InputTask struct {
 	TaskType        uint8
 	TaskRequirement string
 	TaskRelatedDoc  string
 	CandidateText   string
+	// Chart is the image of a Writing Task 1, it is uploaded along with the prompt
+	Chart *lineApiLib.File
+	// PromptVersion selects the prompt template, the version of the agent applies when it is empty
+	PromptVersion string
+	// Rubric scores the task, the IELTS Writing criteria of the task type are asked for when it is nil
+	Rubric     *Rubric
+	OnProgress ProgressFunc
 }
 
-func createInputPromptDemo(input InputTask) string {
-	if input.TaskType == 1 {
-		return fmt.Sprintf(`
-- You are tasked with evaluating and scoring IELTS writing task %d. Your goal is to provide detailed feedback and improvement advice to the student. - Content of task is : ((( %s ))) - Candidate response is: ((( %s ))) - I do not include chart. Please provide your evaluation based on given task. Following below structure for returning: Details: 1) Task Achievement: - Band score: - How to improve: - Strengths: 2) Coherence and Cohesion: - Band score: - How to improve: - Strengths: 3) Lexical Resource: - Band score: - How to improve: - Strengths: 4) Grammatical Range and Accuracy: - Band score: - How to improve: - Strengths: Overall Score: Suggest Essay:`, input.TaskType, input.TaskRequirement, input.CandidateText)
-	}
-
-	return fmt.Sprintf(`
-- You are tasked with evaluating and scoring IELTS writing task %d. Your goal is to provide detailed feedback and improvement advice to the student. - Content of task is : ((( %s ))) - Candidate response is: ((( %s ))) - I do not include chart. Please provide your evaluation based on given task. Following below structure for returning: Details: 1) Task Response: - Band score: - How to improve: - Strengths: 2) Coherence and Cohesion: - Band score: - How to improve: - Strengths: 3) Lexical Resource: - Band score: - How to improve: - Strengths: 4) Grammatical Range and Accuracy: - Band score: - How to improve: - Strengths: Overall Score: Suggest Essay:`, input.TaskType, input.TaskRequirement, input.CandidateText)
-
-}
-
-func createInputPrompt(input InputTask) string {
-	if input.TaskType == 1 {
-		return fmt.Sprintf(`
-You are IELTS teacher for assessing Writing Task %d. Please help provide assessment IELTS band score.
-Given task is : %s
-Candidate response is: %s
-I do not include chart. Please provide your evaluation based on given task. Following below json structure for returning:
-{
-  "details": [
-    {
-      "name": "task-achievement",
-      "band_score": "",
-      "how_to_improve": "",
-      "strengths": ""
-    },
-    {
-      "name": "coherence-and-cohesion",
-      "band_score": "",
-      "how_to_improve": "",
-      "strengths": ""
-    },
-    {
-      "name": "lexical-resource",
-      "band_score": "",
-      "how_to_improve": "",
-      "strengths": ""
-    },
-    {
-      "name": "grammatical-range-and-accuracy",
-      "band_score": "",
-      "how_to_improve": "",
-      "strengths": ""
-    }
-  ],
-  "overall_score": "",
-  "suggest_essay": ""
-}`, input.TaskType, input.TaskRequirement, input.CandidateText)
-	}
-
-	return fmt.Sprintf(`
-You are IELTS teacher for assessing Writing Task %d. Please help provide assessment IELTS band score.
-Given task is : %s
-Candidate response is: %s
-Please provide your evaluation based on given task. Following below json structure for returning:
-{
-  "details": [
-    {
-      "name": "task-response",
-      "band_score": "",
-      "how_to_improve": "",
-      "strengths": ""
-    },
-    {
-      "name": "coherence-and-cohesion",
-      "band_score": "",
-      "how_to_improve": "",
-      "strengths": ""
-    },
-    {
-      "name": "lexical-resource",
-      "band_score": "",
-      "how_to_improve": "",
-      "strengths": ""
-    },
-    {
-      "name": "grammatical-range-and-accuracy",
-      "band_score": "",
-      "how_to_improve": "",
-      "strengths": ""
-    }
-  ],
-  "overall_score": "",
-  "suggest_essay": ""
-}`, input.TaskType, input.TaskRequirement, input.CandidateText)
-
-}
-
-func DoAssessmentV1(ctx context.Context, userID string, input InputTask) (result string, err error) {
-	api := lineApiLib.New()
-	api.Token = os.Getenv("COPILOT_TOKEN")
-	conversationId := os.Getenv("COPILOT_CONVERSATION_ID")
-	//Send messages
-	prompt := createInputPromptDemo(input)
-	fmt.Println("PROMPT:", prompt)
-	resp, err1 := api.SendMessage(ctx, conversationId, userID, prompt)
-	if err1 != nil {
-		err = err1
+// AssessmentReply is the reply of the bot along with the prompt which produced it
+type AssessmentReply struct {
+	Text string
+	// Prompt is the key of the prompt template as "<exam>/<task>/<version>"
+	Prompt string
+	// Flags lists the injection attempts found in the candidate text, see SanitizeText
+	Flags []string
+	// Model names the model which replied, Usage counts its tokens when the backend reports them
+	Model string
+	Usage Usage
+}
+
+// Usage counts the tokens which are billed for an assessment
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+func (i InputTask) report(stage string, detail string) {
+	if i.OnProgress != nil {
+		i.OnProgress(stage, detail)
+	}
+}
+
+// Agent assesses candidate tasks with a Copilot agent through Direct Line,
+// or with a model served through the chat completions protocol, see NewChatAgent
+type Agent struct {
+	api            lineApiLib.IDirectLineAPI
+	conversationID string
+	pool           *ConversationPool
+	chat           openai.IChatCompletionsAPI
+	responseFormat string
+	prompts        *PromptRegistry
+	promptVersion  string
+}
+
+// AgentOption configures an Agent
+type AgentOption func(*Agent)
+
+// WithConversationPool makes DoAssessmentV1 talk in a pooled conversation of its own
+// instead of the shared conversation
+func WithConversationPool(pool *ConversationPool) AgentOption {
+	return func(a *Agent) {
+		a.pool = pool
+	}
+}
+
+// WithPrompts sets the registry of the prompt templates
+func WithPrompts(prompts *PromptRegistry) AgentOption {
+	return func(a *Agent) {
+		if prompts != nil {
+			a.prompts = prompts
+		}
+	}
+}
+
+// WithPromptVersion sets the version of the prompt which DoAssessmentV1 sends when the task requests none
+func WithPromptVersion(version string) AgentOption {
+	return func(a *Agent) {
+		if strings.TrimSpace(version) != "" {
+			a.promptVersion = version
+		}
+	}
+}
+
+// PromptVersion returns the version of the prompt which is sent for the requested version
+func (a *Agent) PromptVersion(requested string) string {
+	if strings.TrimSpace(requested) == "" {
+		return a.promptVersion
+	}
+
+	return strings.TrimSpace(requested)
+}
+
+// NewAgent creates an agent on the given Direct Line client,
+// DoAssessmentV1 talks in the given conversation while DoAssessment starts a new one
+func NewAgent(api lineApiLib.IDirectLineAPI, conversationID string, opts ...AgentOption) *Agent {
+	a := &Agent{
+		api:            api,
+		conversationID: conversationID,
+		promptVersion:  PromptVersionProse,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.prompts == nil {
+		a.prompts = DefaultPromptRegistry()
+	}
+
+	return a
+}
+
+func (a *Agent) DoAssessmentV1(
+	ctx context.Context, userID string, input InputTask,
+) (result AssessmentReply, err error) {
+	input, flags := sanitizeInput(input)
+	key := a.prompts.writingPromptKey(input, a.promptVersion)
+	prompt, err := a.prompts.Render(key, input)
+	if err != nil {
 		return
 	}
 
-	if strings.TrimSpace(resp.ID) == "" {
-		err = errors.New("SendMessage:ERR")
+	result, err = a.converse(ctx, userID, key, prompt, input.Chart, input.report)
+	if err != nil {
 		return
 	}
 
-	sentID := resp.ID
-	watermark := getWatermark(sentID)
+	result.Prompt = key.String()
+	result.Flags = flags
+	return
+}
 
-	//Receive messages
-ReceiveMessage:
-	sleepTime := time.Duration(1)
-	for {
-		time.Sleep(sleepTime * time.Second)
-		resp1, err1 := api.ReceiveMessages(ctx, conversationId, watermark)
+// converse sends the prompt in the shared or a pooled conversation and returns the reply of the bot.
+// The bot is asked again while it replies it can not understand the prompt
+func (a *Agent) converse(
+	ctx context.Context, userID string, key PromptKey, prompt string, chart *lineApiLib.File, report ProgressFunc,
+) (reply AssessmentReply, err error) {
+	if a.chat != nil {
+		return a.complete(ctx, userID, key, prompt, chart, report)
+	}
+
+	api := a.api
+	conversationId := a.conversationID
+	streamURL := ""
+	if a.pool != nil {
+		conv, err1 := a.pool.Acquire(ctx)
 		if err1 != nil {
 			err = err1
 			return
 		}
 
-		if len(resp1.Activities) == 0 {
-			sleepTime *= 2
-			continue
+		defer func() { a.pool.Release(conv, err == nil) }()
+		api = conv.API()
+		conversationId = conv.ID
+		streamURL = conv.streamURL
+	}
+
+	stream := openStream(ctx, api, conversationId, streamURL)
+	if stream != nil {
+		defer stream.Close()
+	}
+
+	//Send messages
+	message := prompt
+	for attempt := 0; ; attempt++ {
+		// Only the first message carries the chart
+		file := chart
+		if attempt > 0 {
+			file = nil
+		}
+
+		resp, err1 := sendMessage(ctx, api, conversationId, userID, message, file)
+		if err1 != nil {
+			err = err1
+			return
+		}
+
+		if strings.TrimSpace(resp.ID) == "" {
+			err = errors.New("SendMessage:ERR")
+			return
 		}
 
-		msgStr := resp1.Activities[len(resp1.Activities)-1]
+		report(ProgressSentToBot, resp.ID)
+
+		//Receive messages
+		msg, err1 := receiveReply(ctx, api, stream, conversationId, resp.ID, report)
+		if err1 != nil {
+			err = err1
+			return
+		}
 
 		//Tricky send message to force it return assessment instead noise us to rephrase again our prompt
-		if strings.Contains(msgStr.Text, "I'm sorry, I'm not sure how to help with that. Can you try rephrasing?") {
-			resp, err1 := api.SendMessage(ctx, conversationId, userID, "What 's problem?")
-			if err1 != nil {
-				err = err1
-				return
-			}
-			sentID := resp.ID
-			watermark = getWatermark(sentID)
-			goto ReceiveMessage
+		if strings.Contains(msg.Text, rephraseReply) && attempt < maxRephrase {
+			report(ProgressPartialReply, msg.Text)
+			message = "What 's problem?"
+			continue
 		}
 
-		msgStr.Text = strings.TrimLeft(msgStr.Text, "There was no problem with your previous request. Here is the evaluation based on the given task and candidate response:")
-		return strings.TrimRight(msgStr.Text, msgStr.Speak), nil
+		return AssessmentReply{Text: msg.Text, Model: ModelName}, nil
 	}
-
 }
 
-func DoAssessment(ctx context.Context, userID string, input InputTask) (result string, err error) {
-	api := lineApiLib.New()
+func (a *Agent) DoAssessment(
+	ctx context.Context, userID string, input InputTask,
+) (result AssessmentReply, err error) {
+	input, flags := sanitizeInput(input)
+	key := a.prompts.writingPromptKey(input, PromptVersionJSON)
+	prompt, err := a.prompts.Render(key, input)
+	if err != nil {
+		return
+	}
+
 	//Generate token
-	token, err := api.GenerateToken(ctx)
+	token, err := a.api.GenerateToken(ctx)
 	if err != nil {
 		return
 	}
@@ -178,6 +253,8 @@ func DoAssessment(ctx context.Context, userID string, input InputTask) (result s
 		return
 	}
 
+	api := a.api.WithToken(token.Token)
+
 	//Start conversation
 	conversation, err := api.StartConversation(ctx)
 	if err != nil {
@@ -189,10 +266,13 @@ func DoAssessment(ctx context.Context, userID string, input InputTask) (result s
 		return
 	}
 
+	stream := openStream(ctx, api, conversation.ConversationId, conversation.StreamUrl)
+	if stream != nil {
+		defer stream.Close()
+	}
+
 	//Send messages
-	prompt := createInputPrompt(input)
-	fmt.Println("PROMPT:", prompt)
-	resp, err1 := api.SendMessage(ctx, conversation.ConversationId, userID, prompt)
+	resp, err1 := sendMessage(ctx, api, conversation.ConversationId, userID, prompt, input.Chart)
 	if err1 != nil {
 		err = err1
 		return
@@ -203,34 +283,152 @@ func DoAssessment(ctx context.Context, userID string, input InputTask) (result s
 		return
 	}
 
-	sentID := resp.ID
-	watermark := getWatermark(sentID)
+	input.report(ProgressSentToBot, resp.ID)
 
 	//Receive messages
-	sleepTime := time.Duration(1)
+	msg, err := receiveReply(ctx, api, stream, conversation.ConversationId, resp.ID, input.report)
+	if err != nil {
+		return
+	}
+
+	return AssessmentReply{Text: msg.Text, Prompt: key.String(), Flags: flags, Model: ModelName}, nil
+}
+
+// sendMessage sends the message as a plain activity or uploads it along with the file when there is one
+func sendMessage(
+	ctx context.Context,
+	api lineApiLib.IDirectLineAPI,
+	conversationID string,
+	userID string,
+	message string,
+	file *lineApiLib.File,
+) (lineApiLib.SendMessageResp, error) {
+	if file == nil {
+		return api.SendMessage(ctx, conversationID, userID, message)
+	}
+
+	return api.UploadFile(ctx, conversationID, userID, message, *file)
+}
+
+// openStream connects to the websocket stream of a conversation, a nil stream means replies have to be polled
+func openStream(
+	ctx context.Context, api lineApiLib.IDirectLineAPI, conversationID string, streamURL string,
+) *lineApiLib.ActivityStream {
+	if strings.TrimSpace(streamURL) == "" {
+		conversation, err := api.ReconnectConversation(ctx, conversationID, "")
+		if err != nil {
+			log.Println("ReconnectConversation:ERR:", err)
+			return nil
+		}
+
+		streamURL = conversation.StreamUrl
+	}
+
+	if strings.TrimSpace(streamURL) == "" {
+		return nil
+	}
+
+	stream, err := api.StreamActivities(ctx, conversationID, streamURL)
+	if err != nil {
+		log.Println("StreamActivities:ERR:", err)
+		return nil
+	}
+
+	return stream
+}
+
+// receiveReply waits for the bot message replying to the sent activity.
+// It listens to the stream when there is one and falls back to polling when the stream ends
+func receiveReply(
+	ctx context.Context,
+	api lineApiLib.IDirectLineAPI,
+	stream *lineApiLib.ActivityStream,
+	conversationID string,
+	sentID string,
+	report ProgressFunc,
+) (lineApiLib.Activity, error) {
+	if stream != nil {
+		report(ProgressWaiting, "stream")
+		select {
+		case msg, ok := <-stream.Reply(sentID):
+			if ok {
+				return msg, nil
+			}
+
+			log.Println("StreamActivities:ERR:", stream.Err())
+		case <-ctx.Done():
+			return lineApiLib.Activity{}, ctx.Err()
+		}
+	}
+
+	return pollReply(ctx, api, conversationID, sentID, report)
+}
+
+// pollReply calls ReceiveMessages with a doubling interval until the reply arrives
+func pollReply(
+	ctx context.Context,
+	api lineApiLib.IDirectLineAPI,
+	conversationID string,
+	sentID string,
+	report ProgressFunc,
+) (lineApiLib.Activity, error) {
+	watermark := getWatermark(sentID)
+	sleepTime := time.Second
 	for {
-		time.Sleep(sleepTime * time.Second)
-		resp1, err1 := api.ReceiveMessages(ctx, conversation.ConversationId, watermark)
-		if err1 != nil {
-			err = err1
-			return
+		if err := wait(ctx, sleepTime); err != nil {
+			return lineApiLib.Activity{}, err
+		}
+
+		resp, err := api.ReceiveMessages(ctx, conversationID, watermark)
+		if err != nil {
+			return lineApiLib.Activity{}, err
 		}
 
-		if len(resp1.Activities) == 0 {
-			sleepTime *= 2
+		sleepTime = nextPollInterval(sleepTime)
+		if len(resp.Activities) == 0 {
+			report(ProgressWaiting, sleepTime.String())
 			continue
 		}
 
-		msg := resp1.Activities[len(resp1.Activities)-1]
-		if msg.ReplyToId == sentID {
-			return strings.TrimLeft(msg.Text, msg.Speak), nil
+		for _, msg := range resp.Activities {
+			if msg.Type == lineApiLib.DefaultMessageType && msg.ReplyToId == sentID {
+				return msg, nil
+			}
 		}
-		sleepTime *= 2
+
+		report(ProgressPartialReply, resp.Activities[len(resp.Activities)-1].Text)
+	}
+}
+
+// wait sleeps for the given duration unless the context is done before
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// nextPollInterval doubles the polling interval up to maxPollInterval
+func nextPollInterval(d time.Duration) time.Duration {
+	d *= 2
+	if d > maxPollInterval {
+		return maxPollInterval
 	}
+
+	return d
 }
 
 func getWatermark(sentID string) int {
 	sentIDs := strings.Split(sentID, "|")
+	if len(sentIDs) < 2 {
+		return 0
+	}
+
 	watermark, err := strconv.Atoi(sentIDs[1])
 	if err != nil {
 		log.Println("SendMessage:ConvertWatermarkERR:", err)
@@ -0,0 +1,218 @@
+package copilotAgent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3/fake"
+)
+
+var testTask = InputTask{
+	TaskType:        1,
+	TaskRequirement: "The chart shows the number of visitors to three museums. Summarise the information.",
+	CandidateText:   "The chart illustrates how many people visited three museums between 2010 and 2020.",
+}
+
+func newTestServer(t *testing.T, opts ...fake.Option) *fake.Server {
+	t.Helper()
+	server := fake.NewServer(opts...)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(server *fake.Server, opts ...lineApiLib.Option) lineApiLib.IDirectLineAPI {
+	return lineApiLib.New(append([]lineApiLib.Option{
+		lineApiLib.WithBaseURL(server.URL),
+		lineApiLib.WithSecret(fake.DefaultSecret),
+	}, opts...)...)
+}
+
+// userMessages counts the messages which the agent sent in a conversation
+func userMessages(server *fake.Server, conversationID string) int {
+	count := 0
+	for _, activity := range server.Activities(conversationID) {
+		if activity.From.Role == "user" {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestDoAssessment(t *testing.T) {
+	server := newTestServer(t)
+	agent := NewAgent(newTestClient(server), "")
+
+	var stages []string
+	input := testTask
+	input.OnProgress = func(stage string, detail string) { stages = append(stages, stage) }
+
+	reply, err := agent.DoAssessment(context.Background(), "student-1", input)
+	if err != nil {
+		t.Fatalf("DoAssessment() error = %v", err)
+	}
+
+	if reply.Text != fake.SampleAssessment || reply.Model != ModelName || reply.Prompt == "" || len(reply.Flags) != 0 {
+		t.Errorf("DoAssessment() = %+v", reply)
+	}
+
+	if len(stages) == 0 || stages[0] != ProgressSentToBot {
+		t.Errorf("progress = %v, want %s first", stages, ProgressSentToBot)
+	}
+}
+
+func TestDoAssessmentV1(t *testing.T) {
+	server := newTestServer(t)
+	conversationID := server.StartConversation()
+	agent := NewAgent(newTestClient(server), conversationID)
+
+	input := testTask
+	input.Chart = &lineApiLib.File{Name: "chart.png", ContentType: "image/png", Data: []byte("\x89PNG\r\n\x1a\n")}
+	reply, err := agent.DoAssessmentV1(context.Background(), "student-1", input)
+	if err != nil {
+		t.Fatalf("DoAssessmentV1() error = %v", err)
+	}
+
+	if reply.Text != fake.SampleAssessment || reply.Prompt != (PromptKey{Exam: ExamIELTSWriting, Task: "task1", Version: PromptVersionProse}).String() {
+		t.Errorf("DoAssessmentV1() = %+v", reply)
+	}
+
+	if uploads := server.Uploads(); len(uploads) != 1 || uploads[0].ConversationID != conversationID || uploads[0].FileName != "chart.png" {
+		t.Errorf("uploads = %+v, want the chart in the shared conversation", uploads)
+	}
+}
+
+func TestDoAssessmentV1Rephrase(t *testing.T) {
+	server := newTestServer(t)
+	server.EnqueueReplies(fake.Reply{Text: fake.SorryReply}, fake.Reply{Text: fake.SampleAssessment})
+	conversationID := server.StartConversation()
+
+	reply, err := NewAgent(newTestClient(server), conversationID).DoAssessmentV1(context.Background(), "student-1", testTask)
+	if err != nil {
+		t.Fatalf("DoAssessmentV1() error = %v", err)
+	}
+
+	if reply.Text != fake.SampleAssessment || userMessages(server, conversationID) != 2 {
+		t.Errorf("DoAssessmentV1() = %q after %d messages, want the assessment after 2", reply.Text, userMessages(server, conversationID))
+	}
+}
+
+func TestDoAssessmentTimeout(t *testing.T) {
+	server := newTestServer(t)
+	conversationID := server.StartConversation()
+	agent := NewAgent(newTestClient(server), conversationID)
+
+	tests := []struct {
+		name   string
+		assess func(ctx context.Context) (AssessmentReply, error)
+	}{
+		{name: "DoAssessment", assess: func(ctx context.Context) (AssessmentReply, error) {
+			return agent.DoAssessment(ctx, "student-1", testTask)
+		}},
+		{name: "DoAssessmentV1", assess: func(ctx context.Context) (AssessmentReply, error) {
+			return agent.DoAssessmentV1(ctx, "student-1", testTask)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The bot never answers
+			server.EnqueueReplies(fake.Reply{Silent: true})
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			_, err := tt.assess(ctx)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("%s() error = %v, want %v", tt.name, err, context.DeadlineExceeded)
+			}
+
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("%s() returned after %s, want it to stop at the deadline", tt.name, elapsed)
+			}
+		})
+	}
+}
+
+func TestDoAssessmentRateLimited(t *testing.T) {
+	tests := []struct {
+		name       string
+		times      int
+		maxRetries int
+		wantErr    error
+	}{
+		{name: "retried after a 429", times: 1, maxRetries: 2},
+		{name: "rate limited when the retries are exhausted", times: 3, maxRetries: 2, wantErr: lineApiLib.ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			conversationID := server.StartConversation()
+			server.InjectFault(fake.Fault{
+				Endpoint:   fake.EndpointActivities,
+				StatusCode: http.StatusTooManyRequests,
+				Code:       "TooManyRequests",
+				Times:      tt.times,
+			})
+
+			agent := NewAgent(newTestClient(server, lineApiLib.WithMaxRetries(tt.maxRetries)), conversationID)
+			reply, err := agent.DoAssessmentV1(context.Background(), "student-1", testTask)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("DoAssessmentV1() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			// The throttled requests never reach the bot, the message is posted once
+			if reply.Text != fake.SampleAssessment || userMessages(server, conversationID) != 1 {
+				t.Errorf("DoAssessmentV1() = %q after %d messages", reply.Text, userMessages(server, conversationID))
+			}
+		})
+	}
+}
+
+func TestDoAssessmentTokenRefresh(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	token, err := newTestClient(server).GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The token is within the refresh window of the manager
+	tokens := lineApiLib.NewTokenManager(
+		lineApiLib.New(lineApiLib.WithBaseURL(server.URL)),
+		lineApiLib.WithInitialToken(token.Token, time.Now().Add(time.Minute)),
+	)
+	agent := NewAgent(
+		lineApiLib.New(lineApiLib.WithBaseURL(server.URL), lineApiLib.WithTokenSource(tokens)),
+		token.ConversationId,
+	)
+
+	reply, err := agent.DoAssessmentV1(ctx, "student-1", testTask)
+	if err != nil {
+		t.Fatalf("DoAssessmentV1() error = %v", err)
+	}
+
+	if reply.Text != fake.SampleAssessment {
+		t.Errorf("DoAssessmentV1() = %q", reply.Text)
+	}
+
+	if refreshed, _ := tokens.Token(ctx); refreshed == token.Token || time.Until(tokens.ExpiresAt()) <= time.Minute {
+		t.Errorf("token expires at %s, want it refreshed", tokens.ExpiresAt())
+	}
+
+	// Once every token has expired on the server the calls are refused
+	server.ExpireTokens()
+	_, err = agent.DoAssessmentV1(ctx, "student-1", testTask)
+	if !errors.Is(err, lineApiLib.ErrTokenExpired) {
+		t.Fatalf("DoAssessmentV1() error = %v, want %v", err, lineApiLib.ErrTokenExpired)
+	}
+}
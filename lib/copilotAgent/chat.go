@@ -0,0 +1,85 @@
+package copilotAgent
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/openai"
+)
+
+// WithResponseFormat sets the response format which a chat agent requests, see openai.ResponseFormatJSON.
+// A chat agent which requests json sends the json-v1 prompts, they describe the json structure of the reply
+func WithResponseFormat(format string) AgentOption {
+	return func(a *Agent) {
+		a.responseFormat = format
+	}
+}
+
+// NewChatAgent creates an agent which sends the prompts to a chat completions API, e.g. OpenAI, Azure OpenAI or vLLM.
+// Every assessment is a conversation of its own, the Direct Line options do not apply
+func NewChatAgent(api openai.IChatCompletionsAPI, opts ...AgentOption) *Agent {
+	a := NewAgent(nil, "", opts...)
+	a.chat = api
+	a.pool = nil
+	if a.responseFormat == openai.ResponseFormatJSON {
+		a.promptVersion = PromptVersionJSON
+	}
+
+	return a
+}
+
+// complete sends the prompt as a chat completion, the chart is attached as an image.
+// The json response format is only requested with a prompt which asks for json
+func (a *Agent) complete(
+	ctx context.Context, userID string, key PromptKey, prompt string, chart *lineApiLib.File, report ProgressFunc,
+) (reply AssessmentReply, err error) {
+	if a.responseFormat == openai.ResponseFormatJSON && key.Version != PromptVersionJSON {
+		err = fmt.Errorf("%w: %s does not ask for the json response format", ErrUnknownPrompt, key)
+		return
+	}
+
+	var format *openai.ResponseFormat
+	if a.responseFormat != "" {
+		format = &openai.ResponseFormat{Type: a.responseFormat}
+	}
+
+	message := openai.ChatMessage{Role: openai.RoleUser, Content: prompt}
+	if chart != nil {
+		message.Parts = []openai.ContentPart{
+			{Type: "text", Text: prompt},
+			{Type: "image_url", ImageURL: &openai.ImageURL{URL: dataURL(chart)}},
+		}
+	}
+
+	report(ProgressSentToBot, key.String())
+	resp, err := a.chat.CreateChatCompletion(ctx, openai.ChatCompletionReq{
+		Messages:       []openai.ChatMessage{message},
+		ResponseFormat: format,
+		User:           userID,
+	})
+	if err != nil {
+		return
+	}
+
+	return AssessmentReply{
+		Text:  resp.Text(),
+		Model: resp.Model,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+// dataURL encodes the file as a data url, the content type is detected from the data when missing
+func dataURL(file *lineApiLib.File) string {
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(file.Data)
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(file.Data))
+}
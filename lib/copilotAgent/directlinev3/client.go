@@ -0,0 +1,291 @@
+package directlinev3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultUserAgent  = "quizgame-ai-serving/directlinev3"
+	DefaultMaxRetries = 3
+
+	baseRetryDelay = 500 * time.Millisecond
+	maxRetryDelay  = 30 * time.Second
+	// maxErrorBody bounds how much of an unsuccessful response is kept in the error
+	maxErrorBody = 4 << 10
+)
+
+var defaultHTTPClient = &http.Client{Transport: http.DefaultTransport}
+
+// TokenSource provides the bearer token which authorizes the Direct Line calls
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource which always returns the same token
+type StaticToken string
+
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// Option configures a Direct Line client
+type Option func(*directLine)
+
+// WithBaseURL points the client to another Direct Line endpoint, e.g. a regional or a local one
+func WithBaseURL(baseURL string) Option {
+	return func(h *directLine) {
+		if strings.TrimSpace(baseURL) != "" {
+			h.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithSecret sets the Direct Line secret which is used to generate tokens
+// and to authorize the calls when there is no token source
+func WithSecret(secret string) Option {
+	return func(h *directLine) {
+		h.secret = secret
+	}
+}
+
+// WithTokenSource sets the provider of the bearer token
+func WithTokenSource(tokens TokenSource) Option {
+	return func(h *directLine) {
+		h.tokens = tokens
+	}
+}
+
+// WithHTTPClient sets the http client which sends the requests
+func WithHTTPClient(client *http.Client) Option {
+	return func(h *directLine) {
+		if client != nil {
+			h.client = client
+		}
+	}
+}
+
+// WithTimeout bounds the duration of every attempt of a call, zero disables it
+func WithTimeout(timeout time.Duration) Option {
+	return func(h *directLine) {
+		h.timeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests
+func WithUserAgent(userAgent string) Option {
+	return func(h *directLine) {
+		if strings.TrimSpace(userAgent) != "" {
+			h.userAgent = userAgent
+		}
+	}
+}
+
+// WithMaxRetries sets how many times a request is sent again after a 429 response, a 5xx response to a GET,
+// or a connection which fails before the request is written. Zero disables the retries
+func WithMaxRetries(maxRetries int) Option {
+	return func(h *directLine) {
+		if maxRetries >= 0 {
+			h.maxRetries = maxRetries
+		}
+	}
+}
+
+// url joins the base url and the given path
+func (h *directLine) url(path string, args ...any) string {
+	return fmt.Sprintf("%s/%s", h.baseURL, strings.TrimLeft(fmt.Sprintf(path, args...), "/"))
+}
+
+// bearer returns the token of the token source, the secret is used when there is none
+func (h *directLine) bearer(ctx context.Context) (string, error) {
+	if h.tokens == nil {
+		return h.secret, nil
+	}
+
+	return h.tokens.Token(ctx)
+}
+
+// WithToken returns a copy of the client which is authorized with the given token,
+// e.g. the token of a single conversation
+func (h *directLine) WithToken(token string) IDirectLineAPI {
+	clone := *h
+	clone.tokens = StaticToken(token)
+	return &clone
+}
+
+// newRequest builds a request bound to the context and authorized with the bearer token
+func (h *directLine) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	token, err := h.bearer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("User-Agent", h.userAgent)
+	return req, nil
+}
+
+// doJSON sends the payload as json and decodes the response body into data
+func (h *directLine) doJSON(ctx context.Context, method, url string, payload any, data any) error {
+	var body io.Reader
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := h.newRequest(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return h.do(req, data)
+}
+
+// do sends the request and decodes the response body into data, every attempt has its own timeout.
+// A POST could have been processed when it fails, so that it is only retried after a 429 response
+// or a connection which fails before the request is written. The other methods are also retried after a 5xx response
+// or a broken connection. The retries wait a jittered exponential backoff, or the delay requested by Retry-After
+func (h *directLine) do(req *http.Request, data any) error {
+	for attempt := 0; ; attempt++ {
+		written, err := h.attempt(req, data)
+		if err == nil || attempt >= h.maxRetries || req.Context().Err() != nil || !retryable(req.Method, written, err) {
+			return err
+		}
+
+		var retryAfter time.Duration
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			retryAfter = apiErr.RetryAfter
+		}
+
+		if sleep(req.Context(), retryDelay(attempt, retryAfter)) != nil {
+			return err
+		}
+	}
+}
+
+// attempt sends a copy of the request bound to the timeout of the attempt,
+// it tells whether the request was written to the connection
+func (h *directLine) attempt(req *http.Request, data any) (bool, error) {
+	ctx, cancel := h.withTimeout(req.Context())
+	defer cancel()
+
+	var written atomic.Bool
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				written.Store(true)
+			}
+		},
+	})
+
+	attemptReq, err := rewind(ctx, req)
+	if err != nil {
+		return false, err
+	}
+
+	err = h.send(attemptReq, data)
+	return written.Load(), err
+}
+
+// retryable tells whether a failed request could be sent again
+func retryable(method string, written bool, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || (apiErr.Temporary() && method != http.MethodPost)
+	}
+
+	// The server never saw a request which was not written
+	return errors.As(err, new(*url.Error)) && (!written || method != http.MethodPost)
+}
+
+// send sends the request once, an unsuccessful status is returned as an APIError
+func (h *directLine) send(req *http.Request, data any) error {
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return newAPIError(resp, body)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(body, data)
+}
+
+// rewind returns a copy of the request bound to the context, whose body can be sent again
+func rewind(ctx context.Context, req *http.Request) (*http.Request, error) {
+	clone := req.Clone(ctx)
+	if req.Body == nil || req.GetBody == nil {
+		return clone, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	clone.Body = body
+	return clone, nil
+}
+
+// retryDelay returns the delay before the given retry, the delay requested by the server wins when there is one
+func retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryDelay)
+	}
+
+	backoff := min(baseRetryDelay<<attempt, maxRetryDelay)
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+func (h *directLine) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, h.timeout)
+}
+
+// streamDialer is the dialer of the websocket stream, a long lived connection must not time out
+func (h *directLine) streamDialer() *websocket.Dialer {
+	return newStreamDialer(h.client)
+}
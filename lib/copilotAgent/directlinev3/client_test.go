@@ -0,0 +1,99 @@
+package directlinev3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failFirst fails the first round trip before the request is written, then sends the requests
+type failFirst struct {
+	failed atomic.Bool
+}
+
+func (t *failFirst) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.failed.CompareAndSwap(false, true) {
+		return nil, errors.New("dial tcp: connection refused")
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestDoRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		responses []int
+		transport http.RoundTripper
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "POST is not retried after a 5xx", method: http.MethodPost, responses: []int{503, 200}, wantCalls: 1, wantErr: true},
+		{name: "POST is retried after a 429", method: http.MethodPost, responses: []int{429, 200}, wantCalls: 2},
+		{name: "GET is retried after a 5xx", method: http.MethodGet, responses: []int{503, 200}, wantCalls: 2},
+		{name: "POST is retried when it is not written", method: http.MethodPost, responses: []int{200}, transport: &failFirst{}, wantCalls: 1},
+		{name: "POST is not retried when the connection breaks after it is written", method: http.MethodPost, responses: []int{-1, 200}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.responses[min(int(calls.Add(1))-1, len(tt.responses)-1)]
+				if status < 0 {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+
+				w.WriteHeader(status)
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: tt.transport}
+			h := New(WithBaseURL(server.URL), WithSecret("secret"), WithHTTPClient(client), WithMaxRetries(2))
+			var data struct{}
+			err := h.doJSON(context.Background(), tt.method, h.url("resource"), struct{}{}, &data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("server calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoTimeoutPerAttempt(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// The first attempt outlives its timeout
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+
+			return
+		}
+
+		w.Write([]byte(`{"watermark": "1"}`))
+	}))
+	defer server.Close()
+
+	h := New(WithBaseURL(server.URL), WithSecret("secret"), WithTimeout(100*time.Millisecond), WithMaxRetries(1))
+	data, err := h.ReceiveMessages(context.Background(), "conversation", 0)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+
+	if data.Watermark != "1" || calls.Load() != 2 {
+		t.Errorf("ReceiveMessages() = %+v after %d calls, want watermark 1 after 2 calls", data, calls.Load())
+	}
+}
@@ -0,0 +1,119 @@
+package directlinev3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tokenExpiredCode is the Direct Line error code of a request authorized with an expired token
+const tokenExpiredCode = "TokenExpired"
+
+var (
+	// ErrUnauthorized is returned when Direct Line rejects the secret or the token
+	ErrUnauthorized = errors.New("directline request is unauthorized")
+	// ErrNotFound is returned when the conversation does not exist anymore
+	ErrNotFound = errors.New("directline resource is not found")
+	// ErrRateLimited is returned when Direct Line throttles the requests
+	ErrRateLimited = errors.New("directline request is rate limited")
+	// ErrUnavailable is returned when Direct Line or the bot behind it fails
+	ErrUnavailable = errors.New("directline service is unavailable")
+)
+
+// APIError describes a response of Direct Line whose status is not successful
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	// RetryAfter is the delay requested by the Retry-After header, zero when there is none
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("directline: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Code)
+	}
+
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+
+	return msg
+}
+
+// Is matches the error with the sentinel errors of its status code
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrTokenExpired:
+		return e.Code == tokenExpiredCode
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// Temporary reports whether the request could succeed when it is sent again
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// errorBody is the body of an unsuccessful Direct Line response
+type errorBody struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var data errorBody
+	if err := json.Unmarshal(body, &data); err == nil {
+		e.Code = data.Error.Code
+		e.Message = data.Error.Message
+	}
+
+	if e.Message == "" {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	return e
+}
+
+// parseRetryAfter reads the Retry-After header which is either a number of seconds or a date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
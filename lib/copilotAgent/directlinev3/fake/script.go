@@ -0,0 +1,80 @@
+package fake
+
+import (
+	"time"
+
+	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+)
+
+// Endpoints of the fake server, a Fault is injected into one of them
+const (
+	EndpointGenerateToken = "tokens/generate"
+	EndpointRefreshToken  = "tokens/refresh"
+	EndpointConversations = "conversations"
+	EndpointReconnect     = "reconnect"
+	EndpointActivities    = "activities"
+	EndpointUpload        = "upload"
+	EndpointStream        = "stream"
+)
+
+// SorryReply is the reply of the Copilot agent when it does not understand the prompt
+const SorryReply = "I'm sorry, I'm not sure how to help with that. Can you try rephrasing?"
+
+// SampleAssessment is a reply in the prose structure requested by the demo prompt
+const SampleAssessment = `Details:
+1) Task Achievement:
+- Band score: 6.5
+- How to improve: Give a clearer overview of the main trends.
+- Strengths: Key features are selected and reported accurately.
+2) Coherence and Cohesion:
+- Band score: 7
+- How to improve: Vary the linking devices.
+- Strengths: Information is logically organised.
+3) Lexical Resource:
+- Band score: 6.5
+- How to improve: Use more precise vocabulary to describe changes.
+- Strengths: A sufficient range of vocabulary.
+4) Grammatical Range and Accuracy:
+- Band score: 6
+- How to improve: Reduce errors in complex sentences.
+- Strengths: A mix of simple and complex sentence forms.
+Overall Score: 6.5
+Suggest Essay: The chart illustrates the changes over the period.`
+
+// Reply is a scripted answer of the bot to a user message
+type Reply struct {
+	Text string
+	// Delay postpones the reply, e.g. to exercise the polling backoff or the context deadline
+	Delay time.Duration
+	// Silent drops the reply so that the client waits until its context is done
+	Silent bool
+}
+
+// Responder produces the reply to a user message when no scripted reply is queued
+type Responder func(activity lineApiLib.Activity) Reply
+
+// Fault is an error response injected into the requests of an endpoint
+type Fault struct {
+	// Endpoint is one of the Endpoint constants, an empty endpoint matches every request
+	Endpoint   string
+	StatusCode int
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+	// Times is how many requests fail, zero means every request until ClearFaults is called
+	Times int
+}
+
+// Upload describes a file which has been uploaded with a message
+type Upload struct {
+	ConversationID string
+	ActivityID     string
+	FileName       string
+	ContentType    string
+	Size           int
+}
+
+// defaultResponder answers every message with SampleAssessment
+func defaultResponder(lineApiLib.Activity) Reply {
+	return Reply{Text: SampleAssessment}
+}
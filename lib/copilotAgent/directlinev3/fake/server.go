@@ -0,0 +1,681 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+)
+
+const (
+	// DefaultSecret is the secret accepted by a fake server
+	DefaultSecret = "fake-secret"
+	// DefaultTokenLifetime is the lifetime of the tokens issued by a fake server
+	DefaultTokenLifetime = 30 * time.Minute
+
+	// apiPrefix is the path of the real Direct Line api, it is optional on the fake server
+	apiPrefix = "/v3/directline"
+)
+
+var botAccount = lineApiLib.From{
+	ID:   "fake-bot",
+	Name: "Fake Bot",
+	Role: "bot",
+}
+
+// Bot is an in-memory Direct Line service whose bot answers with scripted replies.
+// It implements tokens, conversations, activities with watermarks, uploads and websocket streams
+type Bot struct {
+	secret        string
+	tokenLifetime time.Duration
+	responder     Responder
+
+	mu            sync.Mutex
+	tokens        map[string]token
+	conversations map[string]*conversation
+	replies       []Reply
+	faults        []*Fault
+	uploads       []Upload
+	streams       map[*wsConn]struct{}
+}
+
+type token struct {
+	conversationID string
+	expiresAt      time.Time
+}
+
+type conversation struct {
+	id          string
+	activities  []lineApiLib.Activity
+	subscribers map[chan lineApiLib.ActivitySet]struct{}
+}
+
+// Option configures a fake bot
+type Option func(*Bot)
+
+// WithSecret sets the accepted secret, an empty secret accepts any bearer
+func WithSecret(secret string) Option {
+	return func(b *Bot) {
+		b.secret = secret
+	}
+}
+
+// WithTokenLifetime sets the lifetime of the issued tokens
+func WithTokenLifetime(lifetime time.Duration) Option {
+	return func(b *Bot) {
+		if lifetime > 0 {
+			b.tokenLifetime = lifetime
+		}
+	}
+}
+
+// WithResponder sets the replies of the bot when no scripted reply is queued
+func WithResponder(responder Responder) Option {
+	return func(b *Bot) {
+		if responder != nil {
+			b.responder = responder
+		}
+	}
+}
+
+// NewBot creates a fake Direct Line service, it is served by NewServer or by any http server
+func NewBot(opts ...Option) *Bot {
+	b := &Bot{
+		secret:        DefaultSecret,
+		tokenLifetime: DefaultTokenLifetime,
+		responder:     defaultResponder,
+		tokens:        map[string]token{},
+		conversations: map[string]*conversation{},
+		streams:       map[*wsConn]struct{}{},
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Server is a fake bot served by an httptest server, its URL is the base url of a Direct Line client
+type Server struct {
+	*httptest.Server
+	*Bot
+}
+
+// NewServer starts a fake Direct Line server, it has to be closed by the caller
+func NewServer(opts ...Option) *Server {
+	bot := NewBot(opts...)
+	return &Server{
+		Server: httptest.NewServer(bot),
+		Bot:    bot,
+	}
+}
+
+// EnqueueReplies scripts the next replies of the bot, one reply per user message
+func (b *Bot) EnqueueReplies(replies ...Reply) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.replies = append(b.replies, replies...)
+}
+
+// InjectFault makes the requests of an endpoint fail
+func (b *Bot) InjectFault(fault Fault) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if fault.StatusCode == 0 {
+		fault.StatusCode = http.StatusInternalServerError
+	}
+
+	b.faults = append(b.faults, &fault)
+}
+
+// ClearFaults removes the injected faults
+func (b *Bot) ClearFaults() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.faults = nil
+}
+
+// ExpireTokens makes every issued token expire
+func (b *Bot) ExpireTokens() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for value, t := range b.tokens {
+		t.expiresAt = time.Now()
+		b.tokens[value] = t
+	}
+}
+
+// DropStreams closes the open websocket streams without a close frame, like a broken connection
+func (b *Bot) DropStreams() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ws := range b.streams {
+		ws.Close()
+	}
+}
+
+// Activities returns the activities of a conversation
+func (b *Bot) Activities(conversationID string) []lineApiLib.Activity {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	conv, ok := b.conversations[conversationID]
+	if !ok {
+		return nil
+	}
+
+	return append([]lineApiLib.Activity(nil), conv.activities...)
+}
+
+// Uploads returns the files which have been uploaded
+func (b *Bot) Uploads() []Upload {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]Upload(nil), b.uploads...)
+}
+
+// StartConversation creates a conversation, e.g. the one configured as COPILOT_CONVERSATION_ID
+func (b *Bot) StartConversation() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.newConversation().id
+}
+
+func (b *Bot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+	parts := strings.Split(path, "/")
+
+	endpoint := ""
+	switch {
+	case path == EndpointGenerateToken && r.Method == http.MethodPost:
+		endpoint = EndpointGenerateToken
+	case path == EndpointRefreshToken && r.Method == http.MethodPost:
+		endpoint = EndpointRefreshToken
+	case path == EndpointConversations && r.Method == http.MethodPost:
+		endpoint = EndpointConversations
+	case len(parts) == 2 && parts[0] == EndpointConversations && r.Method == http.MethodGet:
+		endpoint = EndpointReconnect
+	case len(parts) == 3 && parts[0] == EndpointConversations && parts[2] == EndpointActivities:
+		endpoint = EndpointActivities
+	case len(parts) == 3 && parts[0] == EndpointConversations && parts[2] == EndpointUpload:
+		endpoint = EndpointUpload
+	case len(parts) == 3 && parts[0] == EndpointConversations && parts[2] == EndpointStream:
+		endpoint = EndpointStream
+	default:
+		writeError(w, http.StatusNotFound, "NotFound", "unknown endpoint", 0)
+		return
+	}
+
+	if fault := b.takeFault(endpoint); fault != nil {
+		writeError(w, fault.StatusCode, fault.Code, fault.Message, fault.RetryAfter)
+		return
+	}
+
+	switch endpoint {
+	case EndpointGenerateToken:
+		b.generateToken(w, r)
+	case EndpointRefreshToken:
+		b.refreshToken(w, r)
+	case EndpointConversations:
+		b.startConversation(w, r)
+	case EndpointReconnect:
+		b.reconnect(w, r, parts[1])
+	case EndpointActivities:
+		if r.Method == http.MethodPost {
+			b.postActivity(w, r, parts[1])
+		} else {
+			b.getActivities(w, r, parts[1])
+		}
+	case EndpointUpload:
+		b.upload(w, r, parts[1])
+	case EndpointStream:
+		b.stream(w, r, parts[1])
+	}
+}
+
+func (b *Bot) generateToken(w http.ResponseWriter, r *http.Request) {
+	if !b.isSecret(bearer(r)) {
+		writeError(w, http.StatusForbidden, "BadArgument", "secret is invalid", 0)
+		return
+	}
+
+	b.mu.Lock()
+	conv := b.newConversation()
+	value := b.issueToken(conv.id)
+	b.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, lineApiLib.GenerateTokenResp{
+		ConversationId: conv.id,
+		Token:          value,
+		ExpiresIn:      uint32(b.tokenLifetime.Seconds()),
+	})
+}
+
+func (b *Bot) refreshToken(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	old, ok := b.tokens[bearer(r)]
+	if !ok {
+		writeError(w, http.StatusForbidden, "BadArgument", "token is invalid", 0)
+		return
+	}
+
+	if !time.Now().Before(old.expiresAt) {
+		writeError(w, http.StatusForbidden, "TokenExpired", "token has expired", 0)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lineApiLib.GenerateTokenResp{
+		ConversationId: old.conversationID,
+		Token:          b.issueToken(old.conversationID),
+		ExpiresIn:      uint32(b.tokenLifetime.Seconds()),
+	})
+}
+
+func (b *Bot) startConversation(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	value := bearer(r)
+	var conv *conversation
+	if b.isSecret(value) {
+		conv = b.newConversation()
+		value = b.issueToken(conv.id)
+	} else {
+		// A generated token starts the conversation which it is bound to
+		t, status, code := b.checkToken(value, "")
+		if status != 0 {
+			writeError(w, status, code, "token is not accepted", 0)
+			return
+		}
+
+		conv = b.conversations[t.conversationID]
+	}
+
+	writeJSON(w, http.StatusCreated, lineApiLib.CreatedConversation{
+		ConversationId: conv.id,
+		Token:          value,
+		ExpiresIn:      uint32(b.tokenLifetime.Seconds()),
+		StreamUrl:      streamURL(r, conv.id, value),
+	})
+}
+
+func (b *Bot) reconnect(w http.ResponseWriter, r *http.Request, conversationID string) {
+	conv, ok := b.authorize(w, r, conversationID)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lineApiLib.CreatedConversation{
+		ConversationId: conv.id,
+		Token:          bearer(r),
+		ExpiresIn:      uint32(b.tokenLifetime.Seconds()),
+		StreamUrl:      streamURL(r, conv.id, bearer(r)) + "&watermark=" + r.URL.Query().Get("watermark"),
+	})
+}
+
+func (b *Bot) postActivity(w http.ResponseWriter, r *http.Request, conversationID string) {
+	if _, ok := b.authorize(w, r, conversationID); !ok {
+		return
+	}
+
+	var activity lineApiLib.Activity
+	if err := json.NewDecoder(r.Body).Decode(&activity); err != nil {
+		writeError(w, http.StatusBadRequest, "BadArgument", err.Error(), 0)
+		return
+	}
+
+	id := b.receive(conversationID, activity)
+	writeJSON(w, http.StatusOK, lineApiLib.SendMessageResp{ID: id})
+}
+
+func (b *Bot) getActivities(w http.ResponseWriter, r *http.Request, conversationID string) {
+	conv, ok := b.authorize(w, r, conversationID)
+	if !ok {
+		return
+	}
+
+	b.mu.Lock()
+	set := conv.after(r.URL.Query().Get("watermark"))
+	b.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, set)
+}
+
+func (b *Bot) upload(w http.ResponseWriter, r *http.Request, conversationID string) {
+	if _, ok := b.authorize(w, r, conversationID); !ok {
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BadArgument", err.Error(), 0)
+		return
+	}
+
+	activity := lineApiLib.Activity{
+		Type: lineApiLib.DefaultMessageType,
+		From: lineApiLib.From{ID: r.URL.Query().Get("userId"), Role: "user"},
+	}
+	var files []Upload
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "BadArgument", err.Error(), 0)
+			return
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "BadArgument", err.Error(), 0)
+			return
+		}
+
+		if part.FormName() == "activity" {
+			if err = json.Unmarshal(data, &activity); err != nil {
+				writeError(w, http.StatusBadRequest, "BadArgument", err.Error(), 0)
+				return
+			}
+
+			continue
+		}
+
+		files = append(files, Upload{
+			ConversationID: conversationID,
+			FileName:       part.FileName(),
+			ContentType:    part.Header.Get("Content-Type"),
+			Size:           len(data),
+		})
+	}
+
+	id := b.receive(conversationID, activity)
+
+	b.mu.Lock()
+	for _, f := range files {
+		f.ActivityID = id
+		b.uploads = append(b.uploads, f)
+	}
+	b.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, lineApiLib.SendMessageResp{ID: id})
+}
+
+func (b *Bot) stream(w http.ResponseWriter, r *http.Request, conversationID string) {
+	// Browsers can not set headers on a websocket, the token comes in the query
+	if r.Header.Get("Authorization") == "" {
+		r.Header.Set("Authorization", "Bearer "+r.URL.Query().Get("t"))
+	}
+
+	conv, ok := b.authorize(w, r, conversationID)
+	if !ok {
+		return
+	}
+
+	ws, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	defer ws.Close()
+	updates := make(chan lineApiLib.ActivitySet, 16)
+	b.mu.Lock()
+	backlog := conv.after(r.URL.Query().Get("watermark"))
+	conv.subscribers[updates] = struct{}{}
+	b.streams[ws] = struct{}{}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(conv.subscribers, updates)
+		delete(b.streams, ws)
+		b.mu.Unlock()
+	}()
+
+	closed := make(chan struct{})
+	go func() {
+		ws.waitClosed()
+		close(closed)
+	}()
+
+	if len(backlog.Activities) > 0 && !b.push(ws, backlog) {
+		return
+	}
+
+	for {
+		select {
+		case <-closed:
+			return
+		case set := <-updates:
+			if !b.push(ws, set) {
+				return
+			}
+		}
+	}
+}
+
+func (b *Bot) push(ws *wsConn, set lineApiLib.ActivitySet) bool {
+	payload, err := json.Marshal(set)
+	if err != nil {
+		return false
+	}
+
+	return ws.writeText(payload) == nil
+}
+
+// receive stores a user activity and schedules the reply of the bot
+func (b *Bot) receive(conversationID string, activity lineApiLib.Activity) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	activity.Type = lineApiLib.DefaultMessageType
+	stored := b.conversations[conversationID].append(activity)
+
+	reply := b.responder(stored)
+	if len(b.replies) > 0 {
+		reply = b.replies[0]
+		b.replies = b.replies[1:]
+	}
+
+	if !reply.Silent {
+		go b.answer(conversationID, stored.ID, reply)
+	}
+
+	return stored.ID
+}
+
+func (b *Bot) answer(conversationID string, replyToID string, reply Reply) {
+	if reply.Delay > 0 {
+		time.Sleep(reply.Delay)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.conversations[conversationID].append(lineApiLib.Activity{
+		Type:      lineApiLib.DefaultMessageType,
+		From:      botAccount,
+		Text:      reply.Text,
+		ReplyToId: replyToID,
+	})
+}
+
+// authorize checks the bearer of a conversation request and writes the error response when it is refused
+func (b *Bot) authorize(w http.ResponseWriter, r *http.Request, conversationID string) (*conversation, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	conv, ok := b.conversations[conversationID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "BadArgument", "conversation is not found", 0)
+		return nil, false
+	}
+
+	value := bearer(r)
+	if b.isSecret(value) {
+		return conv, true
+	}
+
+	if _, status, code := b.checkToken(value, conversationID); status != 0 {
+		writeError(w, status, code, "token is not accepted", 0)
+		return nil, false
+	}
+
+	return conv, true
+}
+
+func (b *Bot) checkToken(value string, conversationID string) (token, int, string) {
+	t, ok := b.tokens[value]
+	if !ok {
+		return t, http.StatusForbidden, "BadArgument"
+	}
+
+	if !time.Now().Before(t.expiresAt) {
+		return t, http.StatusForbidden, "TokenExpired"
+	}
+
+	if conversationID != "" && t.conversationID != conversationID {
+		return t, http.StatusForbidden, "BadArgument"
+	}
+
+	return t, 0, ""
+}
+
+func (b *Bot) isSecret(value string) bool {
+	if b.secret == "" {
+		_, isToken := b.tokens[value]
+		return !isToken
+	}
+
+	return value == b.secret
+}
+
+func (b *Bot) issueToken(conversationID string) string {
+	value := uuid.NewString()
+	b.tokens[value] = token{
+		conversationID: conversationID,
+		expiresAt:      time.Now().Add(b.tokenLifetime),
+	}
+
+	return value
+}
+
+func (b *Bot) newConversation() *conversation {
+	conv := &conversation{
+		id:          uuid.NewString(),
+		subscribers: map[chan lineApiLib.ActivitySet]struct{}{},
+	}
+	b.conversations[conv.id] = conv
+	return conv
+}
+
+func (b *Bot) takeFault(endpoint string) *Fault {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, fault := range b.faults {
+		if fault.Endpoint != "" && fault.Endpoint != endpoint {
+			continue
+		}
+
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				b.faults = append(b.faults[:i], b.faults[i+1:]...)
+			}
+		}
+
+		return fault
+	}
+
+	return nil
+}
+
+// append stores the activity with an id whose suffix is its watermark and pushes it to the streams
+func (c *conversation) append(activity lineApiLib.Activity) lineApiLib.Activity {
+	watermark := len(c.activities)
+	activity.ID = fmt.Sprintf("%s|%07d", c.id, watermark)
+	activity.Conversation = lineApiLib.Conversation{ID: c.id}
+	activity.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	c.activities = append(c.activities, activity)
+
+	set := lineApiLib.ActivitySet{
+		Activities: []lineApiLib.Activity{activity},
+		Watermark:  strconv.Itoa(watermark),
+	}
+	for ch := range c.subscribers {
+		select {
+		case ch <- set:
+		default:
+		}
+	}
+
+	return activity
+}
+
+// after returns the activities which follow the watermark, all of them when the watermark is empty
+func (c *conversation) after(watermark string) lineApiLib.ActivitySet {
+	start := 0
+	if n, err := strconv.Atoi(watermark); err == nil {
+		start = n + 1
+	}
+
+	set := lineApiLib.ActivitySet{
+		Activities: []lineApiLib.Activity{},
+		Watermark:  watermark,
+	}
+	if start < len(c.activities) {
+		set.Activities = append(set.Activities, c.activities[start:]...)
+	}
+
+	if len(c.activities) > 0 {
+		set.Watermark = strconv.Itoa(len(c.activities) - 1)
+	}
+
+	return set
+}
+
+func streamURL(r *http.Request, conversationID string, token string) string {
+	return fmt.Sprintf("ws://%s%s/%s/%s/%s?t=%s",
+		r.Host, apiPrefix, EndpointConversations, conversationID, EndpointStream, token)
+}
+
+func bearer(r *http.Request) string {
+	return strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	}
+
+	body := map[string]any{"error": map[string]string{"code": code, "message": message}}
+	writeJSON(w, status, body)
+}
@@ -0,0 +1,49 @@
+package fake
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// upgrader accepts the streams of any origin, the errors are written like the other errors of the fake server
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+	Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		writeError(w, status, "BadArgument", reason.Error(), 0)
+	},
+}
+
+// wsConn is the server side of a websocket which only pushes text messages
+type wsConn struct {
+	conn *websocket.Conn
+}
+
+// upgrade switches the connection of a websocket handshake request,
+// the error response is already written when it fails
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wsConn{conn: conn}, nil
+}
+
+// writeText sends the payload as a text message
+func (c *wsConn) writeText(payload []byte) error {
+	return c.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+// waitClosed blocks until the client goes away, the messages it sends are ignored
+func (c *wsConn) waitClosed() {
+	for {
+		if _, _, err := c.conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
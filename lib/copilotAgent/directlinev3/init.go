@@ -2,7 +2,10 @@ package directlinev3
 
 import (
 	"context"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 )
 
 const (
@@ -14,33 +17,51 @@ const (
 	DefaultMessageType = "message"
 )
 
-var (
-	secret = os.Getenv("COPILOT_SECRET")
-)
-
 type (
 	IDirectLineAPI interface {
 		GenerateToken(context.Context) (GenerateTokenResp, error)
+		RefreshToken(context.Context, string) (GenerateTokenResp, error)
 		StartConversation(context.Context) (CreatedConversation, error)
 		SendMessage(context.Context, string, string, string) (SendMessageResp, error)
+		UploadFile(context.Context, string, string, string, File) (SendMessageResp, error)
 		ReceiveMessages(context.Context, string, int) (ReceiveMessages, error)
+		ReconnectConversation(context.Context, string, string) (CreatedConversation, error)
+		StreamActivities(context.Context, string, string) (*ActivityStream, error)
+		WithToken(string) IDirectLineAPI
 	}
 
 	directLine struct {
-		Token string
+		baseURL    string
+		secret     string
+		userAgent  string
+		timeout    time.Duration
+		maxRetries int
+		client     *http.Client
+		tokens     TokenSource
 	}
 )
 
 var (
 	_ IDirectLineAPI = &directLine{}
+	_ TokenSource    = &TokenManager{}
 )
 
-func init() {
-	//TODO:
-}
+// New creates a Direct Line client, the secret defaults to the COPILOT_SECRET environment variable
+func New(opts ...Option) *directLine {
+	h := &directLine{
+		baseURL:    strings.TrimRight(ApiPath, "/"),
+		secret:     os.Getenv("COPILOT_SECRET"),
+		userAgent:  DefaultUserAgent,
+		timeout:    DefaultTimeout,
+		maxRetries: DefaultMaxRetries,
+		client:     defaultHTTPClient,
+	}
 
-func New() *directLine {
-	return &directLine{}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 type (
@@ -79,10 +100,6 @@ type (
 		ID string `json:"id"`
 	}
 
-	UploadSendFileResp struct {
-		ID string `json:"id"`
-	}
-
 	Activity struct {
 		Type         string       `json:"type"`
 		ID           string       `json:"id"`
@@ -96,6 +113,7 @@ type (
 
 	ReceiveMessages struct {
 		Activities []Activity `json:"activities"`
+		Watermark  string     `json:"watermark"`
 	}
 
 	WritingTaskResp struct {
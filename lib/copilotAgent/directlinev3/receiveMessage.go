@@ -2,12 +2,7 @@ package directlinev3
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
 	"strconv"
-	"strings"
 )
 
 func (h *directLine) ReceiveMessages(
@@ -17,31 +12,8 @@ func (h *directLine) ReceiveMessages(
 	if watermarkNum > 0 {
 		watermarkStr = strconv.Itoa(watermarkNum)
 	}
-	url := fmt.Sprintf("%s/conversations/%s/activities?watermark=%s",
-		strings.TrimRight(ApiPath, "/"), conversationID, watermarkStr)
-	req, err := http.NewRequest(HTTP_GET, url, nil)
-	if err != nil {
-		return
-	}
-
-	if strings.EqualFold(h.Token, "") {
-		h.Token = secret
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", h.Token))
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{Transport: http.DefaultTransport}
-	resp, err := client.Do(req)
-	if err != nil {
-		return
-	}
-
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return
-	}
 
-	err = json.Unmarshal(body, &data)
+	url := h.url("conversations/%s/activities?watermark=%s", conversationID, watermarkStr)
+	err = h.doJSON(ctx, HTTP_GET, url, nil, &data)
 	return
 }
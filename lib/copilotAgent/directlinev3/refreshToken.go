@@ -0,0 +1,21 @@
+package directlinev3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// RefreshToken extends the lifetime of a token which has not expired yet
+func (h *directLine) RefreshToken(ctx context.Context, token string) (data GenerateTokenResp, err error) {
+	req, err := h.newRequest(ctx, HTTP_POST, h.url("tokens/refresh"), bytes.NewBuffer([]byte(`{}`)))
+	if err != nil {
+		return
+	}
+
+	// A token is refreshed with itself
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+	err = h.do(req, &data)
+	return
+}
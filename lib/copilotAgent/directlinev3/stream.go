@@ -0,0 +1,263 @@
+package directlinev3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxStreamReconnects = 5
+	maxReconnectBackoff = 8 * time.Second
+	maxBufferedReplies  = 50
+)
+
+// ErrStreamClosed is returned when the stream ends before the awaited reply arrives
+var ErrStreamClosed = errors.New("directline stream is closed")
+
+// ActivitySet represents the activities which are pushed through the stream url
+type ActivitySet struct {
+	Activities []Activity `json:"activities"`
+	Watermark  string     `json:"watermark"`
+}
+
+// ReconnectConversation returns a fresh stream url of an existing conversation,
+// the stream resumes after the given watermark
+func (h *directLine) ReconnectConversation(
+	ctx context.Context, conversationID string, watermark string,
+) (data CreatedConversation, err error) {
+	url := h.url("conversations/%s?watermark=%s", conversationID, url.QueryEscape(watermark))
+	err = h.doJSON(ctx, HTTP_GET, url, nil, &data)
+	return
+}
+
+// ActivityStream receives the activities of a conversation through its websocket stream url
+type ActivityStream struct {
+	api            *directLine
+	conversationID string
+
+	mu        sync.Mutex
+	waiters   map[string]chan Activity
+	replies   map[string]Activity
+	watermark string
+	err       error
+
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+// StreamActivities connects to the stream url of a conversation and keeps receiving its activities
+// until the context is cancelled or Close is called. A dropped connection is reconnected automatically
+// and resumes from the last received watermark
+func (h *directLine) StreamActivities(
+	ctx context.Context, conversationID string, streamURL string,
+) (*ActivityStream, error) {
+	conn, err := dialWebSocket(ctx, h.streamDialer(), streamURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &ActivityStream{
+		api:            h,
+		conversationID: conversationID,
+		waiters:        map[string]chan Activity{},
+		replies:        map[string]Activity{},
+		done:           make(chan struct{}),
+		cancel:         cancel,
+	}
+
+	go s.run(ctx, conn)
+	return s, nil
+}
+
+// Reply returns a channel which delivers the bot message replying to the given activity id.
+// The channel is closed without a value when the stream ends before the reply arrives
+func (s *ActivityStream) Reply(replyToID string) <-chan Activity {
+	ch := make(chan Activity, 1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if activity, ok := s.replies[replyToID]; ok {
+		delete(s.replies, replyToID)
+		ch <- activity
+		close(ch)
+		return ch
+	}
+
+	select {
+	case <-s.done:
+		close(ch)
+	default:
+		s.waiters[replyToID] = ch
+	}
+
+	return ch
+}
+
+// Err returns the reason why the stream ends
+func (s *ActivityStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Close stops the stream
+func (s *ActivityStream) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+func (s *ActivityStream) run(ctx context.Context, conn *wsConn) {
+	defer s.finish()
+
+	reconnects := 0
+	backoff := time.Second
+	for {
+		received, err := s.consume(ctx, conn)
+		if ctx.Err() != nil {
+			s.setErr(ctx.Err())
+			return
+		}
+
+		// Only connections which keep failing without delivering anything count toward the limit
+		if received {
+			reconnects = 0
+			backoff = time.Second
+		}
+
+		reconnects++
+		if reconnects > maxStreamReconnects {
+			s.setErr(fmt.Errorf("%w: %v", ErrStreamClosed, err))
+			return
+		}
+
+		conn, err = s.reconnect(ctx)
+		for err != nil {
+			reconnects++
+			if reconnects > maxStreamReconnects || sleep(ctx, backoff) != nil {
+				s.setErr(fmt.Errorf("%w: %v", ErrStreamClosed, err))
+				return
+			}
+
+			backoff = min(backoff*2, maxReconnectBackoff)
+			conn, err = s.reconnect(ctx)
+		}
+	}
+}
+
+// consume reads activity sets until the connection drops
+func (s *ActivityStream) consume(ctx context.Context, conn *wsConn) (received bool, err error) {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	for {
+		message, err := conn.ReadMessage()
+		if err != nil {
+			return received, err
+		}
+
+		received = true
+
+		// Direct Line sends empty messages to keep the connection alive
+		if len(strings.TrimSpace(string(message))) == 0 {
+			continue
+		}
+
+		var set ActivitySet
+		if err = json.Unmarshal(message, &set); err != nil {
+			continue
+		}
+
+		s.dispatch(set)
+	}
+}
+
+func (s *ActivityStream) reconnect(ctx context.Context) (*wsConn, error) {
+	s.mu.Lock()
+	watermark := s.watermark
+	s.mu.Unlock()
+
+	conversation, err := s.api.ReconnectConversation(ctx, s.conversationID, watermark)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(conversation.StreamUrl) == "" {
+		return nil, errors.New("ReconnectConversation:ERR")
+	}
+
+	return dialWebSocket(ctx, s.api.streamDialer(), conversation.StreamUrl)
+}
+
+func (s *ActivityStream) dispatch(set ActivitySet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if set.Watermark != "" {
+		s.watermark = set.Watermark
+	}
+
+	for _, activity := range set.Activities {
+		if activity.Type != DefaultMessageType || activity.ReplyToId == "" {
+			continue
+		}
+
+		if ch, ok := s.waiters[activity.ReplyToId]; ok {
+			delete(s.waiters, activity.ReplyToId)
+			ch <- activity
+			close(ch)
+			continue
+		}
+
+		if len(s.replies) >= maxBufferedReplies {
+			for id := range s.replies {
+				delete(s.replies, id)
+				break
+			}
+		}
+		s.replies[activity.ReplyToId] = activity
+	}
+}
+
+func (s *ActivityStream) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *ActivityStream) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, ch := range s.waiters {
+		close(ch)
+		delete(s.waiters, id)
+	}
+	close(s.done)
+}
+
+// sleep waits for the given duration unless the context is done before
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
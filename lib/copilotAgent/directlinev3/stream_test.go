@@ -0,0 +1,111 @@
+package directlinev3_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3/fake"
+)
+
+// reconnects records the watermarks of the ReconnectConversation calls
+type reconnects struct {
+	mu         sync.Mutex
+	watermarks []string
+}
+
+func (r *reconnects) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+		if req.Method == http.MethodGet && len(parts) == 2 && parts[0] == fake.EndpointConversations {
+			r.mu.Lock()
+			r.watermarks = append(r.watermarks, req.URL.Query().Get("watermark"))
+			r.mu.Unlock()
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (r *reconnects) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.watermarks...)
+}
+
+func awaitReply(t *testing.T, stream *lineApiLib.ActivityStream, replyToID string) lineApiLib.Activity {
+	t.Helper()
+	select {
+	case reply, ok := <-stream.Reply(replyToID):
+		if !ok {
+			t.Fatalf("stream is closed before the reply: %v", stream.Err())
+		}
+
+		return reply
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no reply to %s", replyToID)
+	}
+
+	return lineApiLib.Activity{}
+}
+
+func TestStreamReconnectsFromWatermark(t *testing.T) {
+	bot := fake.NewBot()
+	var calls reconnects
+	server := httptest.NewServer(calls.wrap(bot))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	api := lineApiLib.New(lineApiLib.WithBaseURL(server.URL), lineApiLib.WithSecret(fake.DefaultSecret))
+	conversation, err := api.StartConversation(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := api.StreamActivities(ctx, conversation.ConversationId, conversation.StreamUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	bot.EnqueueReplies(fake.Reply{Text: "first reply"}, fake.Reply{Text: "second reply"})
+	first, err := api.SendMessage(ctx, conversation.ConversationId, "student-1", "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply := awaitReply(t, stream, first.ID); reply.Text != "first reply" {
+		t.Fatalf("reply = %q, want the first reply", reply.Text)
+	}
+
+	// The connection breaks, the second reply is pushed through the new connection
+	bot.DropStreams()
+	second, err := api.SendMessage(ctx, conversation.ConversationId, "student-1", "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply := awaitReply(t, stream, second.ID); reply.Text != "second reply" {
+		t.Fatalf("reply = %q, want the second reply", reply.Text)
+	}
+
+	// The user message and the reply which were received before the break are watermarks 0 and 1
+	if watermarks := calls.list(); len(watermarks) != 1 || watermarks[0] != "1" {
+		t.Errorf("reconnect watermarks = %v, want [1]", watermarks)
+	}
+
+	// The first reply is not delivered again after the reconnect
+	select {
+	case reply := <-stream.Reply(first.ID):
+		t.Errorf("first reply is delivered again: %q", reply.Text)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
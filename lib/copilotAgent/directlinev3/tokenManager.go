@@ -0,0 +1,200 @@
+package directlinev3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRefreshBefore is how long before its expiry a token is refreshed
+	DefaultRefreshBefore = 5 * time.Minute
+	// unknownTokenLifetime is assumed for a token whose expiry is not known, Direct Line tokens last 30 minutes
+	unknownTokenLifetime = 30 * time.Minute
+	minRefreshInterval   = 10 * time.Second
+)
+
+// ErrTokenExpired is returned when the Direct Line token has expired and can not be refreshed anymore
+var ErrTokenExpired = errors.New("directline token has expired")
+
+// TokenExpiredError describes a token which has expired
+type TokenExpiredError struct {
+	ExpiredAt time.Time
+	Err       error
+}
+
+func (e *TokenExpiredError) Error() string {
+	msg := fmt.Sprintf("%s at %s", ErrTokenExpired.Error(), e.ExpiredAt.Format(time.RFC3339))
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Err.Error())
+	}
+
+	return msg
+}
+
+func (e *TokenExpiredError) Is(target error) bool {
+	return target == ErrTokenExpired
+}
+
+func (e *TokenExpiredError) Unwrap() error {
+	return e.Err
+}
+
+// TokenManager is a TokenSource which keeps a Direct Line token alive.
+// It generates a token from the secret when it has none and refreshes it before its expiry,
+// it is safe to be shared by several goroutines
+type TokenManager struct {
+	api           IDirectLineAPI
+	refreshBefore time.Duration
+
+	mu             sync.Mutex
+	token          string
+	conversationID string
+	expiresAt      time.Time
+}
+
+// TokenManagerOption configures a TokenManager
+type TokenManagerOption func(*TokenManager)
+
+// WithInitialToken starts the manager with an existing token, e.g. the token of a known conversation.
+// A zero expiry assumes the token has just been issued
+func WithInitialToken(token string, expiresAt time.Time) TokenManagerOption {
+	return func(m *TokenManager) {
+		if strings.TrimSpace(token) == "" {
+			return
+		}
+
+		if expiresAt.IsZero() {
+			expiresAt = time.Now().Add(unknownTokenLifetime)
+		}
+
+		m.token = token
+		m.expiresAt = expiresAt
+	}
+}
+
+// WithRefreshBefore sets how long before its expiry the token is refreshed
+func WithRefreshBefore(d time.Duration) TokenManagerOption {
+	return func(m *TokenManager) {
+		if d > 0 {
+			m.refreshBefore = d
+		}
+	}
+}
+
+// NewTokenManager creates a token manager which generates and refreshes tokens through the given client
+func NewTokenManager(api IDirectLineAPI, opts ...TokenManagerOption) *TokenManager {
+	m := &TokenManager{
+		api:           api,
+		refreshBefore: DefaultRefreshBefore,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Token returns a valid token, it is generated or refreshed when needed
+func (m *TokenManager) Token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	switch {
+	case m.token == "":
+		if err := m.generate(ctx); err != nil {
+			return "", err
+		}
+	case !now.Before(m.expiresAt):
+		return "", &TokenExpiredError{ExpiredAt: m.expiresAt}
+	case !now.Before(m.expiresAt.Add(-m.refreshBefore)):
+		if err := m.refresh(ctx); err != nil {
+			// The current token is still usable, the next call tries again
+			log.Println("RefreshToken:ERR:", err)
+		}
+	}
+
+	return m.token, nil
+}
+
+// ConversationID returns the conversation which the current token is bound to
+func (m *TokenManager) ConversationID() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.conversationID
+}
+
+// ExpiresAt returns the expiry of the current token
+func (m *TokenManager) ExpiresAt() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.expiresAt
+}
+
+// Run refreshes the token ahead of its expiry until the context is done,
+// so that the token stays alive while no call needs it
+func (m *TokenManager) Run(ctx context.Context) {
+	for {
+		wait := time.Until(m.ExpiresAt().Add(-m.refreshBefore))
+		if wait < minRefreshInterval {
+			wait = minRefreshInterval
+		}
+
+		if sleep(ctx, wait) != nil {
+			return
+		}
+
+		if _, err := m.Token(ctx); err != nil {
+			log.Println("TokenManager:ERR:", err)
+		}
+	}
+}
+
+func (m *TokenManager) generate(ctx context.Context) error {
+	data, err := m.api.GenerateToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(data.Token) == "" {
+		return errors.New("GenerateToken:ERR")
+	}
+
+	m.set(data)
+	return nil
+}
+
+func (m *TokenManager) refresh(ctx context.Context) error {
+	data, err := m.api.RefreshToken(ctx, m.token)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(data.Token) == "" {
+		return errors.New("RefreshToken:ERR")
+	}
+
+	m.set(data)
+	return nil
+}
+
+func (m *TokenManager) set(data GenerateTokenResp) {
+	lifetime := time.Duration(data.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = unknownTokenLifetime
+	}
+
+	m.token = data.Token
+	m.expiresAt = time.Now().Add(lifetime)
+	if data.ConversationId != "" {
+		m.conversationID = data.ConversationId
+	}
+}
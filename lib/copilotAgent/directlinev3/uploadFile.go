@@ -1,11 +1,132 @@
 package directlinev3
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strings"
 )
 
-func (h *directLine) Uploadfile(
-	ctx context.Context, userID string, conversationID string,
+const (
+	// MaxUploadBytes bounds the size of a file sent through the upload call
+	MaxUploadBytes = 4 << 20
+
+	activityContentType = "application/vnd.microsoft.activity"
+)
+
+var (
+	// ErrEmptyFile is returned when the uploaded file has no content
+	ErrEmptyFile = errors.New("uploaded file is empty")
+	// ErrFileTooLarge is returned when the uploaded file exceeds MaxUploadBytes
+	ErrFileTooLarge = fmt.Errorf("uploaded file exceeds %d bytes", MaxUploadBytes)
+)
+
+// File is an attachment which is uploaded along with a message
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// contentType returns the declared content type, it is detected from the data when missing
+func (f File) contentType() string {
+	contentType := strings.TrimSpace(f.ContentType)
+	if contentType == "" || contentType == "application/octet-stream" {
+		return http.DetectContentType(f.Data)
+	}
+
+	return contentType
+}
+
+// UploadFile sends a message activity with the file attached to it,
+// the reply of the bot refers to the returned activity id like a message sent with SendMessage
+func (h *directLine) UploadFile(
+	ctx context.Context, conversationID string, userID string, message string, file File,
 ) (data SendMessageResp, err error) {
+	if len(file.Data) == 0 {
+		err = ErrEmptyFile
+		return
+	}
+
+	if len(file.Data) > MaxUploadBytes {
+		err = ErrFileTooLarge
+		return
+	}
+
+	body, contentType, err := newUploadBody(userID, message, file)
+	if err != nil {
+		return
+	}
+
+	url := h.url("conversations/%s/upload?userId=%s", conversationID, url.QueryEscape(userID))
+	req, err := h.newRequest(ctx, HTTP_POST, url, body)
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	err = h.do(req, &data)
 	return
 }
+
+// newUploadBody builds the multipart body made of the activity part followed by the file part
+func newUploadBody(userID string, message string, file File) (*bytes.Buffer, string, error) {
+	activity, err := json.Marshal(SendMessageReq{
+		Locale: DefaultLocale,
+		Type:   DefaultMessageType,
+		From: From{
+			ID:   userID,
+			Name: "User",
+			Role: "user",
+		},
+		Text: message,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	buf := &bytes.Buffer{}
+	mpw := multipart.NewWriter(buf)
+	activityHeader := textproto.MIMEHeader{}
+	activityHeader.Set("Content-Disposition", `form-data; name="activity"`)
+	activityHeader.Set("Content-Type", activityContentType)
+	part, err := mpw.CreatePart(activityHeader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if _, err = part.Write(activity); err != nil {
+		return nil, "", err
+	}
+
+	name := filepath.Base(file.Name)
+	if name == "." || name == string(filepath.Separator) {
+		name = "file"
+	}
+
+	fileHeader := textproto.MIMEHeader{}
+	fileHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, name))
+	fileHeader.Set("Content-Type", file.contentType())
+	part, err = mpw.CreatePart(fileHeader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if _, err = part.Write(file.Data); err != nil {
+		return nil, "", err
+	}
+
+	// The closing boundary has to be written before the request is sent
+	if err = mpw.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf, mpw.FormDataContentType(), nil
+}
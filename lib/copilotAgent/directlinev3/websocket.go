@@ -0,0 +1,67 @@
+package directlinev3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsMaxMessageBytes bounds a message pushed through the stream url
+	wsMaxMessageBytes = 16 << 20
+	// wsCloseTimeout bounds the wait for the close frame to be written
+	wsCloseTimeout = time.Second
+)
+
+// wsConn is the client side of the stream url, Direct Line only pushes text messages through it
+type wsConn struct {
+	conn *websocket.Conn
+}
+
+// dialWebSocket opens a websocket connection on the given ws(s) url
+func dialWebSocket(ctx context.Context, dialer *websocket.Dialer, rawURL string) (*wsConn, error) {
+	conn, resp, err := dialer.DialContext(ctx, rawURL, nil)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("websocket handshake: unexpected status %s: %w", resp.Status, err)
+		}
+
+		return nil, err
+	}
+
+	conn.SetReadLimit(wsMaxMessageBytes)
+	return &wsConn{conn: conn}, nil
+}
+
+// ReadMessage returns the next data message, the pings are answered by the library in between
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	_, message, err := c.conn.ReadMessage()
+	return message, err
+}
+
+// Close sends a close frame and releases the connection
+func (c *wsConn) Close() error {
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = c.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(wsCloseTimeout))
+	return c.conn.Close()
+}
+
+// newStreamDialer builds the dialer of the websocket stream on the transport of the http client,
+// so that the proxy and the tls settings of the calls apply to the stream as well
+func newStreamDialer(client *http.Client) *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+	}
+
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		dialer.Proxy = transport.Proxy
+		dialer.NetDialContext = transport.DialContext
+		dialer.TLSClientConfig = transport.TLSClientConfig
+	}
+
+	return dialer
+}
@@ -0,0 +1,38 @@
+package openai
+
+import (
+	"context"
+)
+
+// CreateChatCompletion sends the conversation and returns the completion of the model.
+// The model, the temperature, the response format and the token limit of the client apply when the request sets none
+func (c *client) CreateChatCompletion(
+	ctx context.Context, reqPayload ChatCompletionReq,
+) (data ChatCompletionResp, err error) {
+	if reqPayload.Model == "" {
+		reqPayload.Model = c.model
+	}
+
+	if reqPayload.Temperature == nil {
+		reqPayload.Temperature = c.temperature
+	}
+
+	if reqPayload.ResponseFormat == nil && c.responseFormat != "" {
+		reqPayload.ResponseFormat = &ResponseFormat{Type: c.responseFormat}
+	}
+
+	if reqPayload.MaxTokens == 0 {
+		reqPayload.MaxTokens = c.maxTokens
+	}
+
+	err = c.postJSON(ctx, "chat/completions", reqPayload, &data)
+	if err != nil {
+		return
+	}
+
+	if len(data.Choices) == 0 {
+		err = ErrEmptyCompletion
+	}
+
+	return
+}
@@ -0,0 +1,251 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultTimeout    = 2 * time.Minute
+	DefaultUserAgent  = "quizgame-ai-serving/openai"
+	DefaultMaxRetries = 3
+
+	baseRetryDelay = time.Second
+	maxRetryDelay  = 30 * time.Second
+	// maxErrorBody bounds how much of an unsuccessful response is kept in the error
+	maxErrorBody = 4 << 10
+)
+
+var defaultHTTPClient = &http.Client{Transport: http.DefaultTransport}
+
+// Option configures a chat completions client
+type Option func(*client)
+
+// WithBaseURL points the client to another server, e.g. "http://localhost:8080/v1" for llama.cpp
+// or "https://<resource>.openai.azure.com/openai/deployments/<deployment>" for Azure OpenAI
+func WithBaseURL(baseURL string) Option {
+	return func(c *client) {
+		if strings.TrimSpace(baseURL) != "" {
+			c.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithAPIKey sets the key which authorizes the calls, a local server may need none
+func WithAPIKey(apiKey string) Option {
+	return func(c *client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithAPIVersion sets the api-version of Azure OpenAI, the key is then sent in the api-key header
+func WithAPIVersion(version string) Option {
+	return func(c *client) {
+		c.apiVersion = strings.TrimSpace(version)
+	}
+}
+
+// WithModel sets the model of the requests which name none
+func WithModel(model string) Option {
+	return func(c *client) {
+		c.model = model
+	}
+}
+
+// WithTemperature sets the sampling temperature of the requests which set none
+func WithTemperature(temperature float64) Option {
+	return func(c *client) {
+		c.temperature = &temperature
+	}
+}
+
+// WithResponseFormat sets the response format of the requests which set none, see ResponseFormatJSON
+func WithResponseFormat(format string) Option {
+	return func(c *client) {
+		c.responseFormat = strings.TrimSpace(format)
+	}
+}
+
+// WithMaxTokens bounds the length of the completions, zero leaves it to the server
+func WithMaxTokens(maxTokens int) Option {
+	return func(c *client) {
+		if maxTokens >= 0 {
+			c.maxTokens = maxTokens
+		}
+	}
+}
+
+// WithHTTPClient sets the http client which sends the requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		if httpClient != nil {
+			c.client = httpClient
+		}
+	}
+}
+
+// WithTimeout bounds the duration of every attempt of a call, zero disables it
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.timeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests
+func WithUserAgent(userAgent string) Option {
+	return func(c *client) {
+		if strings.TrimSpace(userAgent) != "" {
+			c.userAgent = userAgent
+		}
+	}
+}
+
+// WithMaxRetries sets how many times a request is sent again after a 429 response
+// or a connection which fails before the request is written. Zero disables the retries
+func WithMaxRetries(maxRetries int) Option {
+	return func(c *client) {
+		if maxRetries >= 0 {
+			c.maxRetries = maxRetries
+		}
+	}
+}
+
+// ResponseFormat returns the response format which is requested by default
+func (c *client) ResponseFormat() string {
+	return c.responseFormat
+}
+
+// url joins the base url and the given path, the api-version of Azure OpenAI is added to the query
+func (c *client) url(path string) string {
+	u := fmt.Sprintf("%s/%s", c.baseURL, strings.TrimLeft(path, "/"))
+	if c.apiVersion == "" {
+		return u
+	}
+
+	return fmt.Sprintf("%s?%s", u, url.Values{"api-version": {c.apiVersion}}.Encode())
+}
+
+// postJSON sends the payload as json and decodes the response body into data, every attempt has its own timeout.
+// A completion could have been generated and billed when the request fails, so that it is only retried after a 429 response
+// or a connection which fails before the request is written. The retries wait a jittered exponential backoff,
+// or the delay requested by the server
+func (c *client) postJSON(ctx context.Context, path string, payload any, data any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		written, err := c.attempt(ctx, c.url(path), raw, data)
+		if err == nil || attempt >= c.maxRetries || ctx.Err() != nil || !retryable(written, err) {
+			return err
+		}
+
+		var retryAfter time.Duration
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			retryAfter = apiErr.RetryAfter
+		}
+
+		if wait(ctx, retryDelay(attempt, retryAfter)) != nil {
+			return err
+		}
+	}
+}
+
+// attempt posts the body once within the timeout of the attempt, it tells whether the request was written to the connection
+func (c *client) attempt(ctx context.Context, url string, raw []byte, data any) (bool, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var written atomic.Bool
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				written.Store(true)
+			}
+		},
+	})
+
+	err := c.send(ctx, url, raw, data)
+	return written.Load(), err
+}
+
+// retryable tells whether a failed request could be sent again
+func retryable(written bool, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests && apiErr.Temporary()
+	}
+
+	// The server never saw a request which was not written
+	return errors.As(err, new(*url.Error)) && !written
+}
+
+// send posts the body once, an unsuccessful status is returned as an APIError
+func (c *client) send(ctx context.Context, url string, raw []byte, data any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	switch {
+	case c.apiKey == "":
+	case c.apiVersion != "":
+		req.Header.Set("api-key", c.apiKey)
+	default:
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return newAPIError(resp, body)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(data)
+}
+
+// retryDelay returns the delay before the given retry, the delay requested by the server wins when there is one
+func retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryDelay)
+	}
+
+	backoff := min(baseRetryDelay<<attempt, maxRetryDelay)
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// wait sleeps for the given duration unless the context is done before
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
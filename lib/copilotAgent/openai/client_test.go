@@ -0,0 +1,109 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failFirst fails the first round trip before the request is written, then sends the requests
+type failFirst struct {
+	failed atomic.Bool
+}
+
+func (t *failFirst) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.failed.CompareAndSwap(false, true) {
+		return nil, errors.New("dial tcp: connection refused")
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestPostJSONRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []int
+		body      string
+		transport http.RoundTripper
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "not retried after a 5xx", responses: []int{503, 200}, wantCalls: 1, wantErr: true},
+		{name: "retried after a 429", responses: []int{429, 200}, wantCalls: 2},
+		{
+			name:      "not retried after an exhausted quota",
+			responses: []int{429, 200},
+			body:      `{"error": {"code": "insufficient_quota", "message": "quota"}}`,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{name: "retried when it is not written", responses: []int{200}, transport: &failFirst{}, wantCalls: 1},
+		{name: "not retried when the connection breaks after it is written", responses: []int{-1, 200}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.responses[min(int(calls.Add(1))-1, len(tt.responses)-1)]
+				if status < 0 {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+
+				body := tt.body
+				if status == http.StatusOK || body == "" {
+					body = `{}`
+				}
+
+				w.Header().Set("retry-after-ms", "10")
+				w.WriteHeader(status)
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			c := New(WithBaseURL(server.URL), WithHTTPClient(&http.Client{Transport: tt.transport}), WithMaxRetries(2))
+			var data struct{}
+			err := c.postJSON(context.Background(), "chat/completions", struct{}{}, &data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("postJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("server calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPostJSONTimeoutPerAttempt(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// The first attempt is throttled after most of its timeout
+			time.Sleep(80 * time.Millisecond)
+			w.Header().Set("retry-after-ms", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		time.Sleep(80 * time.Millisecond)
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "done"}}]}`))
+	}))
+	defer server.Close()
+
+	c := New(WithBaseURL(server.URL), WithTimeout(150*time.Millisecond), WithMaxRetries(1))
+	resp, err := c.CreateChatCompletion(context.Background(), ChatCompletionReq{})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+
+	if resp.Text() != "done" || calls.Load() != 2 {
+		t.Errorf("CreateChatCompletion() = %q after %d calls, want done after 2 calls", resp.Text(), calls.Load())
+	}
+}
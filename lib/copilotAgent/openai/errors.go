@@ -0,0 +1,115 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnauthorized is returned when the server rejects the api key
+	ErrUnauthorized = errors.New("chat completions request is unauthorized")
+	// ErrRateLimited is returned when the server throttles the requests or the quota is exhausted
+	ErrRateLimited = errors.New("chat completions request is rate limited")
+	// ErrUnavailable is returned when the server or the model fails
+	ErrUnavailable = errors.New("chat completions service is unavailable")
+	// ErrEmptyCompletion is returned when a completion has no choice
+	ErrEmptyCompletion = errors.New("chat completion has no choice")
+)
+
+// APIError describes a response of the server whose status is not successful
+type APIError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+	// RetryAfter is the delay requested by the retry-after-ms or Retry-After header, zero when there is none
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("openai: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Code)
+	}
+
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+
+	return msg
+}
+
+// Is matches the error with the sentinel errors of its status code
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// Temporary reports whether the request could succeed when it is sent again,
+// an exhausted quota is reported with a 429 as well but does not recover
+func (e *APIError) Temporary() bool {
+	if e.Code == "insufficient_quota" {
+		return false
+	}
+
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// errorBody is the body of an unsuccessful response, the code is a string or a number depending on the server
+type errorBody struct {
+	Error struct {
+		Type    string          `json:"type"`
+		Code    json.RawMessage `json:"code"`
+		Message string          `json:"message"`
+	} `json:"error"`
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header),
+	}
+
+	var data errorBody
+	if err := json.Unmarshal(body, &data); err == nil {
+		e.Type = data.Error.Type
+		e.Code = strings.Trim(string(data.Error.Code), `"`)
+		e.Message = data.Error.Message
+	}
+
+	if e.Code == "null" {
+		e.Code = ""
+	}
+
+	if e.Message == "" {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	return e
+}
+
+// parseRetryAfter reads the retry-after-ms header of OpenAI and Azure OpenAI, or the Retry-After header in seconds
+func parseRetryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header.Get("Retry-After"))); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	return 0
+}
@@ -0,0 +1,143 @@
+// Package openai is a client of the OpenAI chat completions protocol,
+// which is also served by Azure OpenAI, vLLM and the llama.cpp server
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	ApiPath = "https://api.openai.com/v1/"
+
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+
+	// ResponseFormatText lets the model reply freely
+	ResponseFormatText = "text"
+	// ResponseFormatJSON makes the model reply a json object, the messages have to ask for json
+	ResponseFormatJSON = "json_object"
+)
+
+type (
+	IChatCompletionsAPI interface {
+		CreateChatCompletion(context.Context, ChatCompletionReq) (ChatCompletionResp, error)
+	}
+
+	client struct {
+		baseURL        string
+		apiKey         string
+		apiVersion     string
+		model          string
+		temperature    *float64
+		responseFormat string
+		maxTokens      int
+		userAgent      string
+		timeout        time.Duration
+		maxRetries     int
+		client         *http.Client
+	}
+)
+
+var _ IChatCompletionsAPI = &client{}
+
+// New creates a chat completions client, the api key defaults to the OPENAI_API_KEY environment variable
+func New(opts ...Option) *client {
+	c := &client{
+		baseURL:    strings.TrimRight(ApiPath, "/"),
+		apiKey:     os.Getenv("OPENAI_API_KEY"),
+		userAgent:  DefaultUserAgent,
+		timeout:    DefaultTimeout,
+		maxRetries: DefaultMaxRetries,
+		client:     defaultHTTPClient,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type (
+	// ChatMessage is a message of the conversation, a user message carries Parts instead of Content to attach images
+	ChatMessage struct {
+		Role    string        `json:"role"`
+		Content string        `json:"content"`
+		Parts   []ContentPart `json:"-"`
+	}
+
+	ContentPart struct {
+		Type     string    `json:"type"`
+		Text     string    `json:"text,omitempty"`
+		ImageURL *ImageURL `json:"image_url,omitempty"`
+	}
+
+	ImageURL struct {
+		URL string `json:"url"`
+	}
+
+	ResponseFormat struct {
+		Type string `json:"type"`
+	}
+
+	ChatCompletionReq struct {
+		Model          string          `json:"model,omitempty"`
+		Messages       []ChatMessage   `json:"messages"`
+		Temperature    *float64        `json:"temperature,omitempty"`
+		MaxTokens      int             `json:"max_tokens,omitempty"`
+		ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+		User           string          `json:"user,omitempty"`
+	}
+
+	ChatCompletionResp struct {
+		ID      string   `json:"id"`
+		Object  string   `json:"object"`
+		Created int64    `json:"created"`
+		Model   string   `json:"model"`
+		Choices []Choice `json:"choices"`
+		Usage   Usage    `json:"usage"`
+	}
+
+	Choice struct {
+		Index        int         `json:"index"`
+		Message      ChatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	}
+
+	// Usage counts the tokens which are billed for a completion
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	}
+)
+
+// MarshalJSON writes the parts as the content when there are some
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{m.Role, m.Content})
+	}
+
+	return json.Marshal(struct {
+		Role    string        `json:"role"`
+		Content []ContentPart `json:"content"`
+	}{m.Role, m.Parts})
+}
+
+// Text returns the content of the first choice
+func (r ChatCompletionResp) Text() string {
+	if len(r.Choices) == 0 {
+		return ""
+	}
+
+	return r.Choices[0].Message.Content
+}
@@ -0,0 +1,163 @@
+package copilotAgent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+)
+
+// ErrUnparsableReply is returned when a bot reply does not contain an assessment
+var ErrUnparsableReply = errors.New("copilot reply can not be parsed as an assessment")
+
+// ParseError describes why a bot reply could not be parsed
+type ParseError struct {
+	Reason string
+	Reply  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnparsableReply.Error(), e.Reason)
+}
+
+func (e *ParseError) Unwrap() error {
+	return ErrUnparsableReply
+}
+
+var (
+	criteriaPattern = regexp.MustCompile(
+		`(?i)(?:\d\)\s*)?(task achievement|task response|coherence and cohesion|fluency and coherence|lexical resource|grammatical range and accuracy|pronunciation)\s*:`)
+	fieldPattern    = regexp.MustCompile(`(?i)(?:-\s*)?(band score|score|level|how to improve|strengths)\s*:`)
+	overallPattern  = regexp.MustCompile(`(?i)overall(?: band)? score\s*:`)
+	suggestPattern  = regexp.MustCompile(`(?i)suggest(?:ed)? (?:essay|answer)\s*:`)
+	markdownPattern = regexp.MustCompile("\\*\\*|__|`{3}(?:json)?")
+)
+
+// ParseWritingTaskResp extracts an IELTS Writing assessment from a bot reply.
+// The reply could be the json structure requested by the json-v1 prompts
+// or the "Details: 1) Task Achievement: - Band score:" prose requested by the prose-v1 prompts
+func ParseWritingTaskResp(reply string) (*lineApiLib.WritingTaskResp, error) {
+	return parseReply(reply, criteriaPattern)
+}
+
+// ParseRubricResp extracts an assessment of the criteria of a rubric from a bot reply,
+// the criteria are found by their titles
+func ParseRubricResp(reply string, rubric *Rubric) (*lineApiLib.WritingTaskResp, error) {
+	if rubric == nil {
+		return ParseWritingTaskResp(reply)
+	}
+
+	titles := rubric.Titles()
+	for i, title := range titles {
+		titles[i] = strings.Join(strings.Fields(regexp.QuoteMeta(title)), `\s+`)
+	}
+
+	return parseReply(reply, regexp.MustCompile(`(?i)(?:\d\)\s*)?(`+strings.Join(titles, "|")+`)\s*:`))
+}
+
+func parseReply(reply string, headerPattern *regexp.Regexp) (*lineApiLib.WritingTaskResp, error) {
+	if strings.TrimSpace(reply) == "" {
+		return nil, &ParseError{Reason: "reply is empty", Reply: reply}
+	}
+
+	if data, ok := parseJSONReply(reply); ok {
+		return data, nil
+	}
+
+	data := parseProseReply(reply, headerPattern)
+	if len(data.Details) == 0 {
+		return nil, &ParseError{Reason: "no assessment criteria found", Reply: reply}
+	}
+
+	return data, nil
+}
+
+func parseJSONReply(reply string) (*lineApiLib.WritingTaskResp, bool) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end <= start {
+		return nil, false
+	}
+
+	var data lineApiLib.WritingTaskResp
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &data); err != nil {
+		return nil, false
+	}
+
+	if len(data.Details) == 0 {
+		return nil, false
+	}
+
+	return &data, true
+}
+
+func parseProseReply(reply string, headerPattern *regexp.Regexp) *lineApiLib.WritingTaskResp {
+	text := markdownPattern.ReplaceAllString(reply, "")
+	data := &lineApiLib.WritingTaskResp{}
+
+	// The overall score and suggested essay follow the last criterion
+	tail := len(text)
+	if loc := overallPattern.FindStringIndex(text); loc != nil {
+		tail = loc[0]
+		rest := text[loc[1]:]
+		if sLoc := suggestPattern.FindStringIndex(rest); sLoc != nil {
+			data.OverallScore = cleanValue(rest[:sLoc[0]])
+			data.SuggestEssay = strings.TrimSpace(rest[sLoc[1]:])
+		} else {
+			data.OverallScore = cleanValue(rest)
+		}
+	} else if loc := suggestPattern.FindStringIndex(text); loc != nil {
+		tail = loc[0]
+		data.SuggestEssay = strings.TrimSpace(text[loc[1]:])
+	}
+
+	body := text[:tail]
+	headers := headerPattern.FindAllStringSubmatchIndex(body, -1)
+	for i, h := range headers {
+		end := len(body)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+
+		criteria := parseCriteriaFields(body[h[1]:end])
+		criteria.Name = toCriteriaName(body[h[2]:h[3]])
+		data.Details = append(data.Details, criteria)
+	}
+
+	return data
+}
+
+func parseCriteriaFields(section string) lineApiLib.Criteria {
+	var criteria lineApiLib.Criteria
+	fields := fieldPattern.FindAllStringSubmatchIndex(section, -1)
+	for i, f := range fields {
+		end := len(section)
+		if i+1 < len(fields) {
+			end = fields[i+1][0]
+		}
+
+		value := cleanValue(section[f[1]:end])
+		switch strings.ToLower(section[f[2]:f[3]]) {
+		case "band score", "score", "level":
+			criteria.BandScore = value
+		case "how to improve":
+			criteria.HowToImprove = value
+		case "strengths":
+			criteria.Strengths = value
+		}
+	}
+
+	return criteria
+}
+
+// toCriteriaName converts a criteria title into the name used by the json structure
+func toCriteriaName(title string) string {
+	return strings.Join(strings.Fields(strings.ToLower(title)), "-")
+}
+
+func cleanValue(value string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(value), "-"))
+}
@@ -0,0 +1,139 @@
+package copilotAgent
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3/fake"
+)
+
+func TestParseWritingTaskResp(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  *lineApiLib.WritingTaskResp
+	}{
+		{
+			name: "json block in text",
+			reply: "Here is the assessment:\n```json\n" +
+				`{"details": [{"name": "task-response", "band_score": "7", "how_to_improve": "Add examples", "strengths": "Clear"}],` +
+				` "overall_score": "7", "suggest_essay": "A better essay"}` + "\n```\nGood luck!",
+			want: &lineApiLib.WritingTaskResp{
+				Details:      []lineApiLib.Criteria{{Name: "task-response", BandScore: "7", HowToImprove: "Add examples", Strengths: "Clear"}},
+				OverallScore: "7",
+				SuggestEssay: "A better essay",
+			},
+		},
+		{
+			name: "prose with markdown bold",
+			reply: "**Details:**\n**1) Task Achievement:**\n- **Band score:** 6.5\n- **How to improve:** Give an overview.\n" +
+				"- **Strengths:** Accurate data.\n**2) Coherence and Cohesion:**\n- **Band score:** 7 -\n- **How to improve:** Vary the linking.\n" +
+				"- **Strengths:** Logical.\n**Overall Score:** 6.5\n**Suggest Essay:** The chart shows the visitors.",
+			want: &lineApiLib.WritingTaskResp{
+				Details: []lineApiLib.Criteria{
+					{Name: "task-achievement", BandScore: "6.5", HowToImprove: "Give an overview.", Strengths: "Accurate data."},
+					{Name: "coherence-and-cohesion", BandScore: "7", HowToImprove: "Vary the linking.", Strengths: "Logical."},
+				},
+				OverallScore: "6.5",
+				SuggestEssay: "The chart shows the visitors.",
+			},
+		},
+		{
+			name:  "prose without overall score",
+			reply: "Details: 1) Lexical Resource: - Score: 6 - How to improve: Be precise. - Strengths: Range. Suggested answer: An answer",
+			want: &lineApiLib.WritingTaskResp{
+				Details:      []lineApiLib.Criteria{{Name: "lexical-resource", BandScore: "6", HowToImprove: "Be precise.", Strengths: "Range."}},
+				SuggestEssay: "An answer",
+			},
+		},
+		{
+			name:  "json without overall score",
+			reply: `{"details": [{"name": "lexical-resource", "band_score": "6"}]}`,
+			want:  &lineApiLib.WritingTaskResp{Details: []lineApiLib.Criteria{{Name: "lexical-resource", BandScore: "6"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWritingTaskResp(tt.reply)
+			if err != nil {
+				t.Fatalf("ParseWritingTaskResp() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWritingTaskResp() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWritingTaskRespSample(t *testing.T) {
+	got, err := ParseWritingTaskResp(fake.SampleAssessment)
+	if err != nil {
+		t.Fatalf("ParseWritingTaskResp() error = %v", err)
+	}
+
+	if len(got.Details) != 4 || got.Details[3].Name != "grammatical-range-and-accuracy" || got.Details[3].BandScore != "6" {
+		t.Errorf("ParseWritingTaskResp() details = %+v", got.Details)
+	}
+
+	if got.OverallScore != "6.5" || got.SuggestEssay != "The chart illustrates the changes over the period." {
+		t.Errorf("ParseWritingTaskResp() overall = %q, essay = %q", got.OverallScore, got.SuggestEssay)
+	}
+}
+
+func TestParseWritingTaskRespUnparsable(t *testing.T) {
+	tests := []struct {
+		name       string
+		reply      string
+		wantReason string
+	}{
+		{name: "empty", reply: " \n", wantReason: "reply is empty"},
+		{name: "apology", reply: fake.SorryReply, wantReason: "no assessment criteria found"},
+		{name: "json without details", reply: `{"overall_score": "7"}`, wantReason: "no assessment criteria found"},
+		{name: "broken json", reply: `{"details": [{"name": "task-response", "band_score": 7`, wantReason: "no assessment criteria found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWritingTaskResp(tt.reply)
+			if !errors.Is(err, ErrUnparsableReply) {
+				t.Fatalf("ParseWritingTaskResp() error = %v, want %v", err, ErrUnparsableReply)
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Reason != tt.wantReason || parseErr.Reply != tt.reply {
+				t.Errorf("ParseWritingTaskResp() error = %#v, want the reason %q", err, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestParseRubricResp(t *testing.T) {
+	rubric := &Rubric{Criteria: []RubricCriterion{{Title: "Content"}, {Title: "Communicative Achievement"}}}
+	reply := "Details: 1) Content: - Score: 4 - How to improve: Answer every point.\n" +
+		"2) Communicative  Achievement: - Score: 3\nOverall Score: 7"
+
+	got, err := ParseRubricResp(reply, rubric)
+	if err != nil {
+		t.Fatalf("ParseRubricResp() error = %v", err)
+	}
+
+	want := &lineApiLib.WritingTaskResp{
+		Details: []lineApiLib.Criteria{
+			{Name: "content", BandScore: "4", HowToImprove: "Answer every point."},
+			{Name: "communicative-achievement", BandScore: "3"},
+		},
+		OverallScore: "7",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRubricResp() = %+v, want %+v", got, want)
+	}
+
+	// The IELTS criteria are not the criteria of the rubric
+	if _, err = ParseRubricResp(fake.SampleAssessment, rubric); !errors.Is(err, ErrUnparsableReply) {
+		t.Errorf("ParseRubricResp() error = %v, want %v", err, ErrUnparsableReply)
+	}
+}
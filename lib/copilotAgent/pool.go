@@ -0,0 +1,256 @@
+package copilotAgent
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+)
+
+const (
+	// DefaultPoolSize bounds the conversations which are open at the same time
+	DefaultPoolSize = 4
+	// DefaultIdleTimeout is how long an unused conversation is kept before it is evicted
+	DefaultIdleTimeout = 10 * time.Minute
+	// DefaultHealthCheckInterval is how often the idle conversations are checked
+	DefaultHealthCheckInterval = time.Minute
+)
+
+// ErrPoolClosed is returned when a conversation is acquired from a closed pool
+var ErrPoolClosed = errors.New("conversation pool is closed")
+
+// PoolOptions contains the settings of a conversation pool
+type PoolOptions struct {
+	MaxSize             int
+	IdleTimeout         time.Duration
+	HealthCheckInterval time.Duration
+}
+
+// PooledConversation is a conversation which is used by a single assessment at a time
+type PooledConversation struct {
+	ID string
+
+	api       lineApiLib.IDirectLineAPI
+	tokens    *lineApiLib.TokenManager
+	streamURL string
+	lastUsed  time.Time
+}
+
+// API returns the Direct Line client which is authorised for this conversation only
+func (c *PooledConversation) API() lineApiLib.IDirectLineAPI {
+	return c.api
+}
+
+// ConversationPool creates conversations with StartConversation and reuses them,
+// so that concurrent assessments never read the replies of each other
+type ConversationPool struct {
+	api  lineApiLib.IDirectLineAPI
+	opts PoolOptions
+
+	slots chan struct{}
+
+	mu     sync.Mutex
+	idle   []*PooledConversation
+	closed bool
+}
+
+// NewConversationPool creates a pool on a Direct Line client which is able to start conversations,
+// i.e. one authorised with the secret
+func NewConversationPool(api lineApiLib.IDirectLineAPI, opts PoolOptions) *ConversationPool {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultPoolSize
+	}
+
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+
+	if opts.HealthCheckInterval <= 0 {
+		opts.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+
+	return &ConversationPool{
+		api:   api,
+		opts:  opts,
+		slots: make(chan struct{}, opts.MaxSize),
+	}
+}
+
+// Acquire returns an idle conversation or starts a new one.
+// It waits while MaxSize conversations are in use, the conversation must be given back with Release
+func (p *ConversationPool) Acquire(ctx context.Context) (*PooledConversation, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	conv, err := p.acquire(ctx)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	return conv, nil
+}
+
+func (p *ConversationPool) acquire(ctx context.Context) (*PooledConversation, error) {
+	for {
+		conv, err := p.popIdle()
+		if err != nil {
+			return nil, err
+		}
+
+		if conv == nil {
+			return p.start(ctx)
+		}
+
+		// Refreshes the conversation token when it is about to expire
+		token, err := conv.tokens.Token(ctx)
+		if err != nil {
+			log.Println("ConversationPool:ERR:", conv.ID, err)
+			continue
+		}
+
+		conv.api = p.api.WithToken(token)
+		return conv, nil
+	}
+}
+
+// Release gives a conversation back to the pool.
+// A conversation which failed is dropped because its stream could still deliver a late reply
+func (p *ConversationPool) Release(conv *PooledConversation, healthy bool) {
+	defer func() { <-p.slots }()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !healthy || p.closed || len(p.idle) >= p.opts.MaxSize {
+		return
+	}
+
+	// A stream url can be used only once, later streams are opened by reconnecting
+	conv.streamURL = ""
+	conv.lastUsed = time.Now()
+	p.idle = append(p.idle, conv)
+}
+
+// Run evicts the idle conversations and checks the health of the remaining ones until the context is done
+func (p *ConversationPool) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.Close()
+			return
+		case <-ticker.C:
+			p.check(ctx)
+		}
+	}
+}
+
+// Close drops the idle conversations, conversations in use are dropped when they are released
+func (p *ConversationPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	p.idle = nil
+}
+
+func (p *ConversationPool) popIdle() (*PooledConversation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+
+	// The most recently used conversation is the most likely to be healthy
+	for len(p.idle) > 0 {
+		conv := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if time.Since(conv.lastUsed) < p.opts.IdleTimeout {
+			return conv, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *ConversationPool) start(ctx context.Context) (*PooledConversation, error) {
+	conversation, err := p.api.StartConversation(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(conversation.ConversationId) == "" || strings.TrimSpace(conversation.Token) == "" {
+		return nil, errors.New("StartConversation:ERR")
+	}
+
+	var expiresAt time.Time
+	if conversation.ExpiresIn > 0 {
+		expiresAt = time.Now().Add(time.Duration(conversation.ExpiresIn) * time.Second)
+	}
+
+	return &PooledConversation{
+		ID:        conversation.ConversationId,
+		api:       p.api.WithToken(conversation.Token),
+		tokens:    lineApiLib.NewTokenManager(p.api, lineApiLib.WithInitialToken(conversation.Token, expiresAt)),
+		streamURL: conversation.StreamUrl,
+		lastUsed:  time.Now(),
+	}, nil
+}
+
+// check evicts the conversations which stayed idle too long and drops the ones which do not answer anymore
+func (p *ConversationPool) check(ctx context.Context) {
+	p.mu.Lock()
+	candidates := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	var healthy []*PooledConversation
+	for _, conv := range candidates {
+		if time.Since(conv.lastUsed) >= p.opts.IdleTimeout {
+			continue
+		}
+
+		if err := p.ping(ctx, conv); err != nil {
+			log.Println("ConversationPool:HealthCheckERR:", conv.ID, err)
+			continue
+		}
+
+		healthy = append(healthy, conv)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	// Conversations could have been released while they were checked
+	p.idle = append(p.idle, healthy...)
+	if len(p.idle) > p.opts.MaxSize {
+		p.idle = p.idle[len(p.idle)-p.opts.MaxSize:]
+	}
+}
+
+// ping keeps the conversation token alive and makes sure the conversation still exists
+func (p *ConversationPool) ping(ctx context.Context, conv *PooledConversation) error {
+	token, err := conv.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	conv.api = p.api.WithToken(token)
+	_, err = conv.api.ReconnectConversation(ctx, conv.ID, "")
+	return err
+}
@@ -0,0 +1,175 @@
+package copilotAgent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	lineApiLib "github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3"
+	"github.com/lk153/quizgame-ai-serving/lib/copilotAgent/directlinev3/fake"
+)
+
+var candidatePattern = regexp.MustCompile(`candidate-\d+`)
+
+// echoCandidate replies with the candidate of the prompt after a delay which differs per candidate,
+// so that the replies of parallel assessments come in another order than the prompts
+func echoCandidate(activity lineApiLib.Activity) fake.Reply {
+	candidate := candidatePattern.FindString(activity.Text)
+	return fake.Reply{
+		Text:  "assessment of " + candidate,
+		Delay: time.Duration(len(activity.Text)%5) * 10 * time.Millisecond,
+	}
+}
+
+func TestConversationPoolParallelAssessments(t *testing.T) {
+	server := newTestServer(t, fake.WithResponder(echoCandidate))
+	pool := NewConversationPool(newTestClient(server), PoolOptions{MaxSize: 3})
+	agent := NewAgent(newTestClient(server), "", WithConversationPool(pool))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(candidate string) {
+			defer wg.Done()
+			input := testTask
+			input.CandidateText = candidate + " " + testTask.CandidateText
+			reply, err := agent.DoAssessmentV1(context.Background(), "student-1", input)
+			if err != nil {
+				t.Errorf("DoAssessmentV1(%s) error = %v", candidate, err)
+				return
+			}
+
+			if reply.Text != "assessment of "+candidate {
+				t.Errorf("DoAssessmentV1(%s) = %q, want the reply to its own prompt", candidate, reply.Text)
+			}
+		}(fmt.Sprintf("candidate-%d", i))
+	}
+
+	wg.Wait()
+
+	// The conversations are reused, no more than MaxSize were needed
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if len(pool.idle) == 0 || len(pool.idle) > 3 {
+		t.Errorf("idle conversations = %d, want 1 to 3", len(pool.idle))
+	}
+}
+
+func TestConversationPoolMaxSize(t *testing.T) {
+	server := newTestServer(t)
+	pool := NewConversationPool(newTestClient(server), PoolOptions{MaxSize: 1})
+
+	first, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err = pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire() error = %v while the pool is full, want %v", err, context.DeadlineExceeded)
+	}
+
+	acquired := make(chan *PooledConversation)
+	go func() {
+		conv, err := pool.Acquire(context.Background())
+		if err != nil {
+			t.Errorf("Acquire() error = %v", err)
+		}
+
+		acquired <- conv
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Acquire() returns before a conversation is released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.Release(first, true)
+	select {
+	case conv := <-acquired:
+		if conv == nil || conv.ID != first.ID {
+			t.Errorf("Acquire() = %+v, want the released conversation %s", conv, first.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Acquire() still waits after a conversation is released")
+	}
+}
+
+func TestConversationPoolRelease(t *testing.T) {
+	server := newTestServer(t)
+	pool := NewConversationPool(newTestClient(server), PoolOptions{MaxSize: 2})
+	ctx := context.Background()
+
+	conv, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool.Release(conv, true)
+	reused, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reused.ID != conv.ID || reused.streamURL != "" {
+		t.Errorf("Acquire() = %s with stream %q, want %s reused without its stream url", reused.ID, reused.streamURL, conv.ID)
+	}
+
+	// A conversation which failed could still deliver a late reply, it is not reused
+	pool.Release(reused, false)
+	fresh, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fresh.ID == conv.ID {
+		t.Errorf("Acquire() = %s, want a new conversation after an unhealthy release", fresh.ID)
+	}
+
+	pool.Release(fresh, true)
+	pool.Close()
+	if _, err = pool.Acquire(ctx); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Acquire() error = %v, want %v", err, ErrPoolClosed)
+	}
+}
+
+func TestConversationPoolCheck(t *testing.T) {
+	server := newTestServer(t)
+	pool := NewConversationPool(newTestClient(server), PoolOptions{MaxSize: 3, IdleTimeout: time.Minute})
+	ctx := context.Background()
+
+	var convs []*PooledConversation
+	for i := 0; i < 3; i++ {
+		conv, err := pool.Acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		convs = append(convs, conv)
+	}
+
+	for _, conv := range convs {
+		pool.Release(conv, true)
+	}
+
+	// The first conversation stayed idle too long, the second one is gone from the service
+	pool.idle[0].lastUsed = time.Now().Add(-time.Hour)
+	server.InjectFault(fake.Fault{Endpoint: fake.EndpointReconnect, StatusCode: http.StatusNotFound, Code: "BadArgument", Times: 1})
+
+	pool.check(ctx)
+	if len(pool.idle) != 1 || pool.idle[0].ID != convs[2].ID {
+		ids := make([]string, 0, len(pool.idle))
+		for _, conv := range pool.idle {
+			ids = append(ids, conv.ID)
+		}
+
+		t.Errorf("idle conversations = %v, want only %s", ids, convs[2].ID)
+	}
+}
@@ -0,0 +1,211 @@
+package copilotAgent
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	// ExamIELTSWriting is the exam of the IELTS Writing prompts
+	ExamIELTSWriting = "ielts-writing"
+
+	// ExamRubric holds the templates which are generated from a rubric,
+	// they apply to the rubrics without a template of their own
+	ExamRubric = "rubric"
+
+	// PromptVersionProse asks for the "Details: 1) Task Achievement: - Band score:" prose
+	PromptVersionProse = "prose-v1"
+	// PromptVersionJSON asks for the json structure of WritingTaskResp
+	PromptVersionJSON = "json-v1"
+
+	promptExt = ".tmpl"
+)
+
+// ErrUnknownPrompt is returned when no template is registered for a prompt key
+var ErrUnknownPrompt = errors.New("prompt template is not found")
+
+// promptFuncs are the functions available to the templates
+var promptFuncs = template.FuncMap{
+	// inc numbers the items of a range from 1
+	"inc": func(i int) int { return i + 1 },
+	// name gives the name of a criterion in the json structure of the reply
+	"name": toCriteriaName,
+}
+
+// Rubric describes the criteria and the scale which a prompt generated from a rubric asks for
+type Rubric struct {
+	// Exam and Task select the template of the rubric, the generic rubric template applies when there is none
+	Exam     string
+	Task     string
+	Title    string
+	Criteria []RubricCriterion
+	// Scale tells the scores to give in words, e.g. "from 0 to 5 in steps of 1"
+	Scale string
+}
+
+// RubricCriterion is a criterion of a Rubric
+type RubricCriterion struct {
+	Title       string
+	Description string
+}
+
+// Titles returns the titles of the criteria
+func (r *Rubric) Titles() []string {
+	titles := make([]string, 0, len(r.Criteria))
+	for _, c := range r.Criteria {
+		titles = append(titles, c.Title)
+	}
+
+	return titles
+}
+
+//go:embed prompts
+var embeddedPrompts embed.FS
+
+// PromptKey identifies a prompt template, it is stored as prompts/<exam>/<task>/<version>.tmpl
+type PromptKey struct {
+	Exam    string
+	Task    string
+	Version string
+}
+
+// String returns the key as "<exam>/<task>/<version>", the form which is recorded with the results
+func (k PromptKey) String() string {
+	return path.Join(k.Exam, k.Task, k.Version)
+}
+
+// PromptRegistry holds the prompt templates keyed by exam, task and version
+type PromptRegistry struct {
+	templates map[PromptKey]*template.Template
+}
+
+// NewPromptRegistry loads the embedded templates,
+// the templates found in dir with the same layout override or extend them
+func NewPromptRegistry(dir string) (*PromptRegistry, error) {
+	r := &PromptRegistry{
+		templates: map[PromptKey]*template.Template{},
+	}
+
+	embedded, err := fs.Sub(embeddedPrompts, "prompts")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.load(embedded); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(dir) != "" {
+		if err = r.load(os.DirFS(dir)); err != nil {
+			return nil, fmt.Errorf("load prompts from %s: %w", dir, err)
+		}
+	}
+
+	return r, nil
+}
+
+// DefaultPromptRegistry returns the embedded templates only
+func DefaultPromptRegistry() *PromptRegistry {
+	r, err := NewPromptRegistry("")
+	if err != nil {
+		panic(fmt.Sprintf("Load embedded prompts failed: %s", err.Error()))
+	}
+
+	return r
+}
+
+// Render executes the template of the key with the given data
+func (r *PromptRegistry) Render(key PromptKey, data any) (string, error) {
+	tmpl, ok := r.templates[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownPrompt, key)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Has reports whether a template is registered for the key
+func (r *PromptRegistry) Has(key PromptKey) bool {
+	_, ok := r.templates[key]
+	return ok
+}
+
+// Versions returns the versions registered for a task of an exam
+func (r *PromptRegistry) Versions(exam string, task string) []string {
+	var versions []string
+	for key := range r.templates {
+		if key.Exam == exam && key.Task == task {
+			versions = append(versions, key.Version)
+		}
+	}
+
+	sort.Strings(versions)
+	return versions
+}
+
+func (r *PromptRegistry) load(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || path.Ext(name) != promptExt {
+			return nil
+		}
+
+		parts := strings.Split(strings.TrimSuffix(name, promptExt), "/")
+		if len(parts) != 3 {
+			return fmt.Errorf("prompt %s is not stored as <exam>/<task>/<version>%s", name, promptExt)
+		}
+
+		raw, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(string(raw))
+		if err != nil {
+			return err
+		}
+
+		r.templates[PromptKey{Exam: parts[0], Task: parts[1], Version: parts[2]}] = tmpl
+		return nil
+	})
+}
+
+// writingPromptKey returns the key of the prompt of a written task, the default version applies when none is requested.
+// A task with a rubric uses the template of the rubric, or the generic rubric template when the rubric has none
+func (r *PromptRegistry) writingPromptKey(input InputTask, defaultVersion string) PromptKey {
+	version := input.PromptVersion
+	if strings.TrimSpace(version) == "" {
+		version = defaultVersion
+	}
+
+	if input.Rubric == nil {
+		return PromptKey{
+			Exam:    ExamIELTSWriting,
+			Task:    fmt.Sprintf("task%d", input.TaskType),
+			Version: version,
+		}
+	}
+
+	key := PromptKey{Exam: input.Rubric.Exam, Task: input.Rubric.Task, Version: version}
+	if r.Has(key) {
+		return key
+	}
+
+	return PromptKey{Exam: ExamRubric, Task: "generic", Version: version}
+}
@@ -0,0 +1,166 @@
+package copilotAgent
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Flags raised by SanitizeText
+const (
+	FlagDelimiter           = "delimiter"
+	FlagHiddenCharacters    = "hidden-characters"
+	FlagInstructionOverride = "instruction-override"
+	FlagScoreRequest        = "score-request"
+	FlagFormatForgery       = "format-forgery"
+	FlagRoleMarker          = "role-marker"
+)
+
+var (
+	// Runs of three brackets, full-width ones included, could close the ((( ... ))) delimiters of the prompt
+	openDelimiterPattern  = regexp.MustCompile(`[(\x{FF08}\x{FE59}\x{207D}\x{208D}]{3,}`)
+	closeDelimiterPattern = regexp.MustCompile(`[)\x{FF09}\x{FE5A}\x{207E}\x{208E}]{3,}`)
+	// Zero-width and bidi control characters could hide instructions from a human reviewer
+	hiddenCharsPattern = regexp.MustCompile(`[\x{200B}-\x{200F}\x{202A}-\x{202E}\x{2060}-\x{2064}\x{FEFF}]`)
+
+	injectionPatterns = []struct {
+		flag    string
+		pattern *regexp.Regexp
+	}{
+		{FlagInstructionOverride, regexp.MustCompile(
+			`(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+|the\s+|your\s+|these\s+)*` +
+				`(previous|prior|above|earlier|preceding|original|system)\s+` +
+				`(instructions?|prompts?|rules|directions|guidelines|criteria)\b`)},
+		{FlagInstructionOverride, regexp.MustCompile(
+			`(?i)\b(ignore|disregard|forget)\s+(all\s+|your\s+)+(instructions?|prompts?)\b`)},
+		{FlagInstructionOverride, regexp.MustCompile(
+			`(?i)\b(new|updated|real|actual)\s+instructions?\s*:|\byou\s+are\s+now\b|\bfrom\s+now\s+on\s+you\b`)},
+		{FlagInstructionOverride, regexp.MustCompile(
+			`(?i)\bend\s+of\s+(the\s+)?(candidate\s+)?(response|essay|text|answer|input)\b`)},
+		{FlagScoreRequest, regexp.MustCompile(
+			`(?i)\b(award|give|assign|grant|rate|score|mark)\b[^.!?\n]{0,40}\bband(\s+score)?\s*(of\s+)?(9|nine)\b`)},
+		{FlagScoreRequest, regexp.MustCompile(
+			`(?i)\b(must|should|will)\s+(get|receive|be\s+given)\s+(a\s+)?band(\s+score)?\s+(of\s+)?(9|nine)\b`)},
+		{FlagFormatForgery, regexp.MustCompile(
+			`(?i)\b(overall\s+(band\s+)?score|band\s+score)\s*:\s*\d`)},
+		{FlagRoleMarker, regexp.MustCompile(
+			`(?im)^\s*(system|assistant|developer)\s*:|<\|?(system|assistant|im_start|im_end)\|?>|\[/?(INST|SYS)\]`)},
+		{FlagRoleMarker, regexp.MustCompile(
+			"(?im)^\\s*(```|~~~)\\s*(system|assistant|developer|instructions?)\\b")},
+	}
+
+	whitespacePattern = regexp.MustCompile(`\s+`)
+
+	// Cyrillic and Greek letters which look like Latin ones, e.g. "іgnоre" written to evade the patterns
+	homoglyphs = strings.NewReplacer(
+		"а", "a", "е", "e", "о", "o", "р", "p", "с", "c", "у", "y", "х", "x", "і", "i", "ј", "j", "ѕ", "s",
+		"А", "A", "В", "B", "І", "I", "Ѕ", "S", "Ј", "J", "Е", "E", "К", "K", "М", "M", "Н", "H", "О", "O", "Р", "P", "С", "C", "Т", "T", "Х", "X",
+		"α", "a", "ε", "e", "ι", "i", "ο", "o", "ρ", "p", "υ", "u", "ν", "v",
+		"Α", "A", "Β", "B", "Ε", "E", "Ι", "I", "Κ", "K", "Μ", "M", "Ν", "N", "Ο", "O", "Ρ", "P", "Τ", "T", "Χ", "X",
+	)
+)
+
+// SanitizedText is a text which is safe to be placed between the delimiters of a prompt
+type SanitizedText struct {
+	Text string
+	// Flags lists the kinds of injection which have been detected, sorted and without duplicates
+	Flags []string
+}
+
+// Suspicious reports whether the text looks like an attempt to steer the assessment
+func (s SanitizedText) Suspicious() bool {
+	return IsSuspicious(s.Flags)
+}
+
+// IsSuspicious reports whether the flags reveal an attempt to steer the assessment,
+// hidden characters alone are usually left by copy and paste
+func IsSuspicious(flags []string) bool {
+	for _, flag := range flags {
+		if flag != FlagHiddenCharacters {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SanitizeText escapes the delimiter sequences of a text, strips its hidden characters
+// and flags the instruction-like phrases it contains. The phrases are kept so that the text is assessed as written
+func SanitizeText(text string) SanitizedText {
+	flags := map[string]bool{}
+
+	if hiddenCharsPattern.MatchString(text) {
+		flags[FlagHiddenCharacters] = true
+		text = hiddenCharsPattern.ReplaceAllString(text, "")
+	}
+
+	if openDelimiterPattern.MatchString(text) || closeDelimiterPattern.MatchString(text) {
+		flags[FlagDelimiter] = true
+		text = openDelimiterPattern.ReplaceAllString(text, "(")
+		text = closeDelimiterPattern.ReplaceAllString(text, ")")
+	}
+
+	for _, flag := range DetectInjection(text) {
+		flags[flag] = true
+	}
+
+	result := SanitizedText{Text: text}
+	for flag := range flags {
+		result.Flags = append(result.Flags, flag)
+	}
+
+	sort.Strings(result.Flags)
+	return result
+}
+
+// DetectInjection returns the kinds of instruction-like phrases found in a text,
+// the look-alike letters are folded before the text is matched
+func DetectInjection(text string) []string {
+	text = foldLookalikes(text)
+	// Line breaks are kept for the role markers which are anchored at the start of a line
+	normalized := whitespacePattern.ReplaceAllStringFunc(text, func(space string) string {
+		if strings.Contains(space, "\n") {
+			return "\n"
+		}
+
+		return " "
+	})
+
+	var flags []string
+	seen := map[string]bool{}
+	for _, p := range injectionPatterns {
+		if seen[p.flag] || !p.pattern.MatchString(normalized) {
+			continue
+		}
+
+		seen[p.flag] = true
+		flags = append(flags, p.flag)
+	}
+
+	return flags
+}
+
+// foldLookalikes replaces the full-width forms and the homoglyphs of the Latin letters by the letters
+func foldLookalikes(text string) string {
+	text = strings.Map(func(r rune) rune {
+		// Full-width ASCII, e.g. "ｉｇｎｏｒｅ"
+		if r >= '\uFF01' && r <= '\uFF5E' {
+			return r - '\uFF01' + '!'
+		}
+
+		return r
+	}, text)
+
+	return homoglyphs.Replace(text)
+}
+
+// sanitizeInput sanitizes the texts of a task which are spliced into the prompt
+func sanitizeInput(input InputTask) (InputTask, []string) {
+	requirement := SanitizeText(input.TaskRequirement)
+	candidate := SanitizeText(input.CandidateText)
+	input.TaskRequirement = requirement.Text
+	input.CandidateText = candidate.Text
+
+	// Only the candidate text is written by the student, the requirement comes from the question bank
+	return input, candidate.Flags
+}
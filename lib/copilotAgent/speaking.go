@@ -0,0 +1,80 @@
+package copilotAgent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ExamIELTSSpeaking is the exam of the IELTS Speaking prompts
+const ExamIELTSSpeaking = "ielts-speaking"
+
+// SpeakingTask is a part of an IELTS Speaking test, it is assessed from the transcript of the candidate's answers
+type SpeakingTask struct {
+	// Part is 1 for the interview, 2 for the long turn and 3 for the discussion
+	Part uint8
+	// Prompts are the questions of the examiner, or the cue card of Part 2
+	Prompts []string
+	// Transcript is the text of the recording, each turn on its own line
+	Transcript string
+	// PromptVersion selects the prompt template, the version of the agent applies when it is empty
+	PromptVersion string
+	OnProgress    ProgressFunc
+}
+
+func (t SpeakingTask) report(stage string, detail string) {
+	if t.OnProgress != nil {
+		t.OnProgress(stage, detail)
+	}
+}
+
+// DoSpeakingAssessmentV1 sends a part of a Speaking test the same way as DoAssessmentV1.
+// The pronunciation can not be heard from a transcript, the bot is asked to tell when it can not judge it
+func (a *Agent) DoSpeakingAssessmentV1(
+	ctx context.Context, userID string, input SpeakingTask,
+) (result AssessmentReply, err error) {
+	input, flags := sanitizeSpeaking(input)
+	key := speakingPromptKey(input, a.promptVersion)
+	prompt, err := a.prompts.Render(key, input)
+	if err != nil {
+		return
+	}
+
+	result, err = a.converse(ctx, userID, key, prompt, nil, input.report)
+	if err != nil {
+		return
+	}
+
+	result.Prompt = key.String()
+	result.Flags = flags
+	return
+}
+
+// speakingPromptKey returns the key of an IELTS Speaking prompt, the default version applies when none is requested
+func speakingPromptKey(input SpeakingTask, defaultVersion string) PromptKey {
+	version := input.PromptVersion
+	if strings.TrimSpace(version) == "" {
+		version = defaultVersion
+	}
+
+	return PromptKey{
+		Exam:    ExamIELTSSpeaking,
+		Task:    fmt.Sprintf("part%d", input.Part),
+		Version: version,
+	}
+}
+
+// sanitizeSpeaking sanitizes the texts of a Speaking part which are spliced into the prompt
+func sanitizeSpeaking(input SpeakingTask) (SpeakingTask, []string) {
+	prompts := make([]string, 0, len(input.Prompts))
+	for _, p := range input.Prompts {
+		prompts = append(prompts, SanitizeText(p).Text)
+	}
+
+	transcript := SanitizeText(input.Transcript)
+	input.Prompts = prompts
+	input.Transcript = transcript.Text
+
+	// Only the transcript is spoken by the candidate, the prompts come from the question bank
+	return input, transcript.Flags
+}
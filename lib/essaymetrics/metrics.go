@@ -0,0 +1,203 @@
+// Package essaymetrics computes deterministic metrics of a written response, e.g. of an IELTS Writing task.
+// They do not need the AI assessor, so they stay available when it is down
+package essaymetrics
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// MinWordsTask1 is the minimum length of a Writing Task 1 response
+	MinWordsTask1 = 150
+	// MinWordsTask2 is the minimum length of a Writing Task 2 response
+	MinWordsTask2 = 250
+	// MaxBandOneWords is the length up to which a response is rated at band 1
+	MaxBandOneWords = 20
+
+	maxRepeatedPhrases = 10
+)
+
+var (
+	wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+(?:['’-][\p{L}\p{N}]+)*`)
+	// sentencePattern does not end a sentence at the decimal point of a number, e.g. "band 6.5"
+	sentencePattern  = regexp.MustCompile(`(?:\d\.\d|[^.!?])+[.!?]*`)
+	paragraphPattern = regexp.MustCompile(`\n\s*\n`)
+)
+
+// Metrics describes the length, structure and vocabulary of a response
+type Metrics struct {
+	WordCount      int          `bson:"word_count" json:"word_count"`
+	MinWords       int          `bson:"min_words" json:"min_words"`
+	UnderLength    bool         `bson:"under_length" json:"under_length"`
+	MissingWords   int          `bson:"missing_words" json:"missing_words"`
+	ParagraphCount int          `bson:"paragraph_count" json:"paragraph_count"`
+	SentenceCount  int          `bson:"sentence_count" json:"sentence_count"`
+	SentenceLength Distribution `bson:"sentence_length" json:"sentence_length"`
+	// TypeTokenRatio is the number of distinct words divided by the number of words
+	TypeTokenRatio float64 `bson:"type_token_ratio" json:"type_token_ratio"`
+	// LinkingWords counts the cohesive devices which are used
+	LinkingWords     map[string]int `bson:"linking_words" json:"linking_words"`
+	LinkingWordCount int            `bson:"linking_word_count" json:"linking_word_count"`
+	RepeatedPhrases  []Phrase       `bson:"repeated_phrases" json:"repeated_phrases"`
+}
+
+// Distribution summarises the number of words per sentence
+type Distribution struct {
+	Min    int     `bson:"min" json:"min"`
+	Max    int     `bson:"max" json:"max"`
+	Mean   float64 `bson:"mean" json:"mean"`
+	Median float64 `bson:"median" json:"median"`
+	StdDev float64 `bson:"std_dev" json:"std_dev"`
+}
+
+// Phrase is a sequence of words which is repeated in the response
+type Phrase struct {
+	Text  string `bson:"text" json:"text"`
+	Count int    `bson:"count" json:"count"`
+}
+
+// MinWords returns the minimum length of the response of a Writing task
+func MinWords(taskType uint8) int {
+	if taskType == 1 {
+		return MinWordsTask1
+	}
+
+	return MinWordsTask2
+}
+
+// Compute measures a response which is expected to be at least minWords long,
+// a response is never under length when minWords is zero
+func Compute(text string, minWords int) *Metrics {
+	words := tokenize(text)
+	m := &Metrics{
+		WordCount:    len(words),
+		MinWords:     minWords,
+		LinkingWords: map[string]int{},
+	}
+
+	if m.WordCount < m.MinWords {
+		m.UnderLength = true
+		m.MissingWords = m.MinWords - m.WordCount
+	}
+
+	m.ParagraphCount = countParagraphs(text)
+
+	var lengths []int
+	for _, sentence := range sentencePattern.FindAllString(text, -1) {
+		if n := len(tokenize(sentence)); n > 0 {
+			lengths = append(lengths, n)
+		}
+	}
+
+	m.SentenceCount = len(lengths)
+	m.SentenceLength = distribution(lengths)
+
+	if m.WordCount > 0 {
+		types := map[string]struct{}{}
+		for _, w := range words {
+			types[w] = struct{}{}
+		}
+
+		m.TypeTokenRatio = round(float64(len(types))/float64(m.WordCount), 3)
+	}
+
+	m.LinkingWords, m.LinkingWordCount = countLinkingWords(words)
+	m.RepeatedPhrases = repeatedPhrases(words)
+	return m
+}
+
+// LengthPenalty returns the bands deducted from the task criterion of an under-length response.
+// The band descriptors penalise such a response without a fixed amount, the deduction grows with the missing words
+func (m *Metrics) LengthPenalty() float64 {
+	if !m.UnderLength || m.MinWords == 0 {
+		return 0
+	}
+
+	missing := float64(m.MissingWords) / float64(m.MinWords)
+	switch {
+	case missing <= 0.1:
+		return 0.5
+	case missing <= 0.3:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// IsBandOneLength reports whether the response is so short that it is rated at band 1
+func (m *Metrics) IsBandOneLength() bool {
+	return m.WordCount <= MaxBandOneWords
+}
+
+// tokenize returns the lower-cased words of a text
+func tokenize(text string) []string {
+	words := wordPattern.FindAllString(text, -1)
+	for i, w := range words {
+		words[i] = strings.ToLower(strings.ReplaceAll(w, "’", "'"))
+	}
+
+	return words
+}
+
+// countParagraphs counts the blocks separated by a blank line, or the lines when there is no blank line
+func countParagraphs(text string) int {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return 0
+	}
+
+	blocks := paragraphPattern.Split(text, -1)
+	if len(blocks) == 1 {
+		blocks = strings.Split(text, "\n")
+	}
+
+	count := 0
+	for _, b := range blocks {
+		if strings.TrimSpace(b) != "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+func distribution(lengths []int) Distribution {
+	if len(lengths) == 0 {
+		return Distribution{}
+	}
+
+	sorted := append([]int(nil), lengths...)
+	sort.Ints(sorted)
+
+	sum := 0
+	for _, n := range sorted {
+		sum += n
+	}
+
+	mean := float64(sum) / float64(len(sorted))
+	variance := 0.0
+	for _, n := range sorted {
+		variance += (float64(n) - mean) * (float64(n) - mean)
+	}
+
+	median := float64(sorted[len(sorted)/2])
+	if len(sorted)%2 == 0 {
+		median = float64(sorted[len(sorted)/2-1]+sorted[len(sorted)/2]) / 2
+	}
+
+	return Distribution{
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Mean:   round(mean, 2),
+		Median: median,
+		StdDev: round(math.Sqrt(variance/float64(len(sorted))), 2),
+	}
+}
+
+func round(value float64, digits int) float64 {
+	pow := math.Pow(10, float64(digits))
+	return math.Round(value*pow) / pow
+}
@@ -0,0 +1,163 @@
+package essaymetrics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComputeCounts(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		wantWords     int
+		wantSentences int
+		wantParas     int
+	}{
+		{name: "empty", text: "  ", wantWords: 0, wantSentences: 0, wantParas: 0},
+		{name: "contractions and hyphens are one word", text: "It's a well-known fact.", wantWords: 4, wantSentences: 1, wantParas: 1},
+		{name: "several terminators", text: "Really?! Yes. No", wantWords: 3, wantSentences: 3, wantParas: 1},
+		{name: "decimal band", text: "I got band 6.5 last year. I want 7.", wantWords: 10, wantSentences: 2, wantParas: 1},
+		{name: "decimal amount", text: "Sales rose to 3.2 million. They fell in 2019. Then they recovered.", wantWords: 13, wantSentences: 3, wantParas: 1},
+		{name: "blank lines separate paragraphs", text: "First one.\nStill first.\n\n  \nSecond one.", wantWords: 6, wantSentences: 3, wantParas: 2},
+		{name: "lines are paragraphs without a blank line", text: "First one.\r\nSecond one.\r\nThird one.", wantWords: 6, wantSentences: 3, wantParas: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Compute(tt.text, 0)
+			if m.WordCount != tt.wantWords || m.SentenceCount != tt.wantSentences || m.ParagraphCount != tt.wantParas {
+				t.Errorf("Compute() words = %d, sentences = %d, paragraphs = %d, want %d, %d, %d",
+					m.WordCount, m.SentenceCount, m.ParagraphCount, tt.wantWords, tt.wantSentences, tt.wantParas)
+			}
+		})
+	}
+}
+
+func TestComputeSentenceLength(t *testing.T) {
+	m := Compute("One two. One two three four. One two three four five six.", 0)
+	want := Distribution{Min: 2, Max: 6, Mean: 4, Median: 4, StdDev: 1.63}
+	if m.SentenceLength != want {
+		t.Errorf("Compute() sentence length = %+v, want %+v", m.SentenceLength, want)
+	}
+}
+
+func TestComputeTypeTokenRatio(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{text: "", want: 0},
+		{text: "every word differs here", want: 1},
+		{text: "The cat saw the cat", want: 0.6},
+		{text: "It is. It’s it's", want: 0.75},
+		{text: "a b c a b c a b c", want: 0.333},
+	}
+
+	for _, tt := range tests {
+		if got := Compute(tt.text, 0).TypeTokenRatio; got != tt.want {
+			t.Errorf("Compute(%q) type token ratio = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestComputeLinkingWords(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		want      map[string]int
+		wantCount int
+	}{
+		{name: "none", text: "The chart shows sales.", want: map[string]int{}, wantCount: 0},
+		{
+			name:      "single and multi-word",
+			text:      "However, sales rose. In addition, costs fell. However, profit was flat.",
+			want:      map[string]int{"however": 2, "in addition": 1},
+			wantCount: 3,
+		},
+		{
+			name:      "the longest match wins",
+			text:      "On the other hand, prices rose. On the contrary, they fell.",
+			want:      map[string]int{"on the other hand": 1, "on the contrary": 1},
+			wantCount: 2,
+		},
+		{name: "case is ignored", text: "THEREFORE it is so. Thus it ends.", want: map[string]int{"therefore": 1, "thus": 1}, wantCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Compute(tt.text, 0)
+			if !reflect.DeepEqual(m.LinkingWords, tt.want) || m.LinkingWordCount != tt.wantCount {
+				t.Errorf("Compute() linking words = %v (%d), want %v (%d)", m.LinkingWords, m.LinkingWordCount, tt.want, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestComputeRepeatedPhrases(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Phrase
+	}{
+		{name: "twice is not repeated", text: "the number of cars rose. the number of cars fell.", want: nil},
+		{
+			name: "the most repeated first",
+			text: "the number of cars rose. the number of cars fell. the number of cars was flat. the number of buses rose.",
+			want: []Phrase{{Text: "the number of", Count: 4}, {Text: "number of cars", Count: 3}},
+		},
+		{name: "stop words alone are not a phrase", text: "it is in it is in it is in", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compute(tt.text, 0).RepeatedPhrases; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compute() repeated phrases = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLengthPenalty(t *testing.T) {
+	tests := []struct {
+		name        string
+		words       int
+		minWords    int
+		wantUnder   bool
+		wantMissing int
+		wantPenalty float64
+		wantBandOne bool
+	}{
+		{name: "long enough", words: 250, minWords: MinWordsTask2, wantPenalty: 0},
+		{name: "no minimum", words: 10, minWords: 0, wantPenalty: 0, wantBandOne: true},
+		{name: "up to 10% missing", words: 225, minWords: MinWordsTask2, wantUnder: true, wantMissing: 25, wantPenalty: 0.5},
+		{name: "up to 30% missing", words: 175, minWords: MinWordsTask2, wantUnder: true, wantMissing: 75, wantPenalty: 1},
+		{name: "more than 30% missing", words: 174, minWords: MinWordsTask2, wantUnder: true, wantMissing: 76, wantPenalty: 2},
+		{name: "task 1", words: 140, minWords: MinWordsTask1, wantUnder: true, wantMissing: 10, wantPenalty: 0.5},
+		{name: "band 1 length", words: MaxBandOneWords, minWords: MinWordsTask1, wantUnder: true, wantMissing: 130, wantPenalty: 2, wantBandOne: true},
+		{name: "over band 1 length", words: MaxBandOneWords + 1, minWords: MinWordsTask1, wantUnder: true, wantMissing: 129, wantPenalty: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Compute(strings.Repeat("word ", tt.words), tt.minWords)
+			if m.UnderLength != tt.wantUnder || m.MissingWords != tt.wantMissing {
+				t.Errorf("Compute() under length = %v, missing = %d, want %v, %d", m.UnderLength, m.MissingWords, tt.wantUnder, tt.wantMissing)
+			}
+
+			if got := m.LengthPenalty(); got != tt.wantPenalty {
+				t.Errorf("LengthPenalty() = %v, want %v", got, tt.wantPenalty)
+			}
+
+			if got := m.IsBandOneLength(); got != tt.wantBandOne {
+				t.Errorf("IsBandOneLength() = %v, want %v", got, tt.wantBandOne)
+			}
+		})
+	}
+}
+
+func TestMinWords(t *testing.T) {
+	if MinWords(1) != MinWordsTask1 || MinWords(2) != MinWordsTask2 {
+		t.Errorf("MinWords() = %d, %d, want %d, %d", MinWords(1), MinWords(2), MinWordsTask1, MinWordsTask2)
+	}
+}
@@ -0,0 +1,100 @@
+package essaymetrics
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	repeatedPhraseWords    = 3
+	repeatedPhraseMinCount = 3
+)
+
+// linkingWords are the cohesive devices which are counted, multi-word ones are matched as a whole
+var linkingWords = []string{
+	"additionally", "although", "as a result", "besides", "consequently", "finally", "firstly",
+	"for example", "for instance", "furthermore", "hence", "however", "in addition", "in conclusion",
+	"in contrast", "in particular", "in summary", "likewise", "meanwhile", "moreover", "nevertheless",
+	"nonetheless", "on the contrary", "on the other hand", "overall", "secondly", "similarly",
+	"such as", "therefore", "thirdly", "thus", "to conclude", "to sum up", "whereas", "while",
+}
+
+// stopWords do not make a phrase on their own
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "this": true, "to": true, "was": true, "were": true, "with": true,
+}
+
+// countLinkingWords counts the linking words, the longest linking word wins where several start at the same word
+func countLinkingWords(words []string) (map[string]int, int) {
+	counts := map[string]int{}
+	total := 0
+	for i := 0; i < len(words); i++ {
+		match, matchLen := "", 0
+		for _, lw := range linkingWords {
+			n := len(strings.Fields(lw))
+			if n <= matchLen || i+n > len(words) {
+				continue
+			}
+
+			if strings.Join(words[i:i+n], " ") == lw {
+				match, matchLen = lw, n
+			}
+		}
+
+		if match == "" {
+			continue
+		}
+
+		counts[match]++
+		total++
+		i += matchLen - 1
+	}
+
+	return counts, total
+}
+
+// repeatedPhrases returns the word sequences which come back several times, the most repeated first
+func repeatedPhrases(words []string) []Phrase {
+	counts := map[string]int{}
+	for i := 0; i+repeatedPhraseWords <= len(words); i++ {
+		gram := words[i : i+repeatedPhraseWords]
+		if onlyStopWords(gram) {
+			continue
+		}
+
+		counts[strings.Join(gram, " ")]++
+	}
+
+	var phrases []Phrase
+	for text, count := range counts {
+		if count >= repeatedPhraseMinCount {
+			phrases = append(phrases, Phrase{Text: text, Count: count})
+		}
+	}
+
+	sort.Slice(phrases, func(i, j int) bool {
+		if phrases[i].Count != phrases[j].Count {
+			return phrases[i].Count > phrases[j].Count
+		}
+
+		return phrases[i].Text < phrases[j].Text
+	})
+
+	if len(phrases) > maxRepeatedPhrases {
+		phrases = phrases[:maxRepeatedPhrases]
+	}
+
+	return phrases
+}
+
+func onlyStopWords(words []string) bool {
+	for _, w := range words {
+		if !stopWords[w] {
+			return false
+		}
+	}
+
+	return true
+}
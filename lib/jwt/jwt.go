@@ -0,0 +1,276 @@
+// Package jwt verifies the signed JSON Web Tokens of the HS256 and RS256 algorithms
+package jwt
+
+import (
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"time"
+
+	jwtLib "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+
+	// DefaultRolesClaim is the claim which lists the roles of the subject
+	DefaultRolesClaim = "roles"
+)
+
+var (
+	// ErrMalformedToken is returned when the token is not made of three base64url encoded parts
+	ErrMalformedToken = errors.New("jwt: token is malformed")
+	// ErrUnsupportedAlgorithm is returned when the token is signed with an algorithm which has no key
+	ErrUnsupportedAlgorithm = errors.New("jwt: signing algorithm is not supported")
+	// ErrUnknownKey is returned when the kid of the token is not one of the keys, or missing while there are several keys
+	ErrUnknownKey = errors.New("jwt: signing key is unknown")
+	// ErrInvalidSignature is returned when the signature does not match the token
+	ErrInvalidSignature = errors.New("jwt: signature is invalid")
+	// ErrMissingExpiration is returned when the token has no exp claim
+	ErrMissingExpiration = errors.New("jwt: token has no expiration")
+	// ErrExpiredToken is returned when the token is past its exp claim
+	ErrExpiredToken = errors.New("jwt: token has expired")
+	// ErrTokenNotYetValid is returned when the token is before its nbf claim
+	ErrTokenNotYetValid = errors.New("jwt: token is not valid yet")
+	// ErrInvalidIssuer is returned when the iss claim is not the expected issuer
+	ErrInvalidIssuer = errors.New("jwt: issuer is invalid")
+	// ErrInvalidAudience is returned when the aud claim does not contain the expected audience
+	ErrInvalidAudience = errors.New("jwt: audience is invalid")
+)
+
+// Claims are the verified claims of a token
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	Roles     []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+
+	raw jwtLib.MapClaims
+}
+
+// Strings returns a claim which lists strings as an array or a space separated string, nil when it is missing
+func (c *Claims) Strings(claim string) []string {
+	return parseStrings(c.raw[claim])
+}
+
+// Verifier checks the signature and the registered claims of the tokens
+type Verifier struct {
+	secret     []byte
+	keys       map[string]*rsa.PublicKey
+	issuer     string
+	audience   string
+	rolesClaim string
+	leeway     time.Duration
+	now        func() time.Time
+}
+
+// Option configures a Verifier
+type Option func(*Verifier)
+
+// WithHMACSecret accepts the HS256 tokens signed with the secret
+func WithHMACSecret(secret []byte) Option {
+	return func(v *Verifier) {
+		v.secret = secret
+	}
+}
+
+// WithRSAKeys accepts the RS256 tokens signed with one of the keys, the keys are indexed by their kid
+func WithRSAKeys(keys map[string]*rsa.PublicKey) Option {
+	return func(v *Verifier) {
+		v.keys = keys
+	}
+}
+
+// WithIssuer requires the iss claim to be the issuer
+func WithIssuer(issuer string) Option {
+	return func(v *Verifier) {
+		v.issuer = strings.TrimSpace(issuer)
+	}
+}
+
+// WithAudience requires the aud claim to contain the audience
+func WithAudience(audience string) Option {
+	return func(v *Verifier) {
+		v.audience = strings.TrimSpace(audience)
+	}
+}
+
+// WithRolesClaim reads the roles from another claim than DefaultRolesClaim
+func WithRolesClaim(claim string) Option {
+	return func(v *Verifier) {
+		if strings.TrimSpace(claim) != "" {
+			v.rolesClaim = claim
+		}
+	}
+}
+
+// WithLeeway tolerates the given clock skew on the exp and nbf claims
+func WithLeeway(leeway time.Duration) Option {
+	return func(v *Verifier) {
+		if leeway >= 0 {
+			v.leeway = leeway
+		}
+	}
+}
+
+// WithClock sets the clock which the exp and nbf claims are checked against
+func WithClock(now func() time.Time) Option {
+	return func(v *Verifier) {
+		if now != nil {
+			v.now = now
+		}
+	}
+}
+
+// New creates a verifier, a token is only accepted for the algorithms which have a key
+func New(opts ...Option) *Verifier {
+	v := &Verifier{
+		rolesClaim: DefaultRolesClaim,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// HasKeys tells whether the verifier could accept any token
+func (v *Verifier) HasKeys() bool {
+	return len(v.secret) > 0 || len(v.keys) > 0
+}
+
+// Verify checks the signature of the token, then its exp, nbf, iss and aud claims.
+// The exp claim is required
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	opts := []jwtLib.ParserOption{
+		jwtLib.WithExpirationRequired(),
+		jwtLib.WithLeeway(v.leeway),
+		jwtLib.WithTimeFunc(v.now),
+	}
+
+	if v.issuer != "" {
+		opts = append(opts, jwtLib.WithIssuer(v.issuer))
+	}
+
+	if v.audience != "" {
+		opts = append(opts, jwtLib.WithAudience(v.audience))
+	}
+
+	raw := jwtLib.MapClaims{}
+	if _, err := jwtLib.NewParser(opts...).ParseWithClaims(token, raw, v.key); err != nil {
+		return nil, v.toError(err, raw)
+	}
+
+	claims := &Claims{
+		Roles: parseStrings(raw[v.rolesClaim]),
+		raw:   raw,
+	}
+	claims.Subject, _ = raw.GetSubject()
+	claims.Issuer, _ = raw.GetIssuer()
+	claims.Audience, _ = raw.GetAudience()
+	if exp, _ := raw.GetExpirationTime(); exp != nil {
+		claims.ExpiresAt = exp.Time
+	}
+
+	if nbf, _ := raw.GetNotBefore(); nbf != nil {
+		claims.NotBefore = nbf.Time
+	}
+
+	return claims, nil
+}
+
+// key picks the key of the algorithm, the algorithm of the header is never trusted to pick another kind of key.
+// A token without kid is only accepted when there is a single RSA key
+func (v *Verifier) key(token *jwtLib.Token) (any, error) {
+	switch method := token.Method.(type) {
+	case *jwtLib.SigningMethodHMAC:
+		if method.Alg() != AlgHS256 || len(v.secret) == 0 {
+			return nil, ErrUnsupportedAlgorithm
+		}
+
+		return v.secret, nil
+	case *jwtLib.SigningMethodRSA:
+		if method.Alg() != AlgRS256 || len(v.keys) == 0 {
+			return nil, ErrUnsupportedAlgorithm
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" && len(v.keys) == 1 {
+			for _, key := range v.keys {
+				return key, nil
+			}
+		}
+
+		key, ok := v.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+
+		return key, nil
+	}
+
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// toError maps the errors of the parser to the errors of this package
+func (v *Verifier) toError(err error, raw jwtLib.MapClaims) error {
+	for _, known := range []error{ErrUnsupportedAlgorithm, ErrUnknownKey} {
+		if errors.Is(err, known) {
+			return known
+		}
+	}
+
+	switch {
+	case errors.Is(err, jwtLib.ErrTokenMalformed):
+		return ErrMalformedToken
+	case errors.Is(err, jwtLib.ErrTokenSignatureInvalid), errors.Is(err, jwtLib.ErrTokenUnverifiable):
+		return ErrInvalidSignature
+	case errors.Is(err, jwtLib.ErrTokenRequiredClaimMissing):
+		// The parser also requires the iss and aud claims which are expected
+		switch {
+		case raw["exp"] == nil:
+			return ErrMissingExpiration
+		case v.issuer != "" && raw["iss"] == nil:
+			return ErrInvalidIssuer
+		}
+
+		return ErrInvalidAudience
+	case errors.Is(err, jwtLib.ErrTokenExpired):
+		return ErrExpiredToken
+	case errors.Is(err, jwtLib.ErrTokenNotValidYet):
+		return ErrTokenNotYetValid
+	case errors.Is(err, jwtLib.ErrTokenInvalidIssuer):
+		return ErrInvalidIssuer
+	case errors.Is(err, jwtLib.ErrTokenInvalidAudience):
+		return ErrInvalidAudience
+	}
+
+	return ErrMalformedToken
+}
+
+// parseStrings reads an array of strings, or a space separated list as in the scope claim
+func parseStrings(raw any) []string {
+	switch value := raw.(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				return nil
+			}
+
+			list = append(list, s)
+		}
+
+		return list
+	}
+
+	return nil
+}
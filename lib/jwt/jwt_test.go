@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	jwtLib "github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testNow    = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func sign(t *testing.T, method jwtLib.SigningMethod, kid string, claims jwtLib.MapClaims, key any) string {
+	t.Helper()
+	token := jwtLib.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func validClaims() jwtLib.MapClaims {
+	return jwtLib.MapClaims{
+		"sub":     "student-1",
+		"iss":     "quizgame",
+		"aud":     "quizgame-ai",
+		"exp":     testNow.Add(time.Hour).Unix(),
+		"roles":   []string{"student"},
+		"classes": "class-a class-b",
+	}
+}
+
+func withClaim(name string, value any) jwtLib.MapClaims {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, name)
+		return claims
+	}
+
+	claims[name] = value
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	key1, key2, other := testRSAKey(t), testRSAKey(t), testRSAKey(t)
+	opts := []Option{
+		WithIssuer("quizgame"),
+		WithAudience("quizgame-ai"),
+		WithClock(func() time.Time { return testNow }),
+		WithLeeway(30 * time.Second),
+	}
+	hmacVerifier := New(append(opts, WithHMACSecret(testSecret))...)
+	rsaVerifier := New(append(opts, WithRSAKeys(map[string]*rsa.PublicKey{"k1": &key1.PublicKey, "k2": &key2.PublicKey}))...)
+	singleKeyVerifier := New(append(opts, WithRSAKeys(map[string]*rsa.PublicKey{"k1": &key1.PublicKey}))...)
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		wantErr  error
+	}{
+		{
+			name:     "valid HS256",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS256, "", validClaims(), testSecret),
+		},
+		{
+			name:     "valid RS256 with kid",
+			verifier: rsaVerifier,
+			token:    sign(t, jwtLib.SigningMethodRS256, "k2", validClaims(), key2),
+		},
+		{
+			name:     "RS256 without kid and a single key",
+			verifier: singleKeyVerifier,
+			token:    sign(t, jwtLib.SigningMethodRS256, "", validClaims(), key1),
+		},
+		{
+			name:     "expired",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS256, "", withClaim("exp", testNow.Add(-time.Minute).Unix()), testSecret),
+			wantErr:  ErrExpiredToken,
+		},
+		{
+			name:     "expired within the leeway",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS256, "", withClaim("exp", testNow.Add(-10*time.Second).Unix()), testSecret),
+		},
+		{
+			name:     "missing exp",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS256, "", withClaim("exp", nil), testSecret),
+			wantErr:  ErrMissingExpiration,
+		},
+		{
+			name:     "not valid yet",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS256, "", withClaim("nbf", testNow.Add(time.Hour).Unix()), testSecret),
+			wantErr:  ErrTokenNotYetValid,
+		},
+		{
+			name:     "wrong alg, HS256 without a secret",
+			verifier: rsaVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS256, "k1", validClaims(), testSecret),
+			wantErr:  ErrUnsupportedAlgorithm,
+		},
+		{
+			name:     "wrong alg, HS512",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS512, "", validClaims(), testSecret),
+			wantErr:  ErrUnsupportedAlgorithm,
+		},
+		{
+			name:     "alg none",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodNone, "", validClaims(), jwtLib.UnsafeAllowNoneSignatureType),
+			wantErr:  ErrUnsupportedAlgorithm,
+		},
+		{
+			name:     "wrong kid",
+			verifier: rsaVerifier,
+			token:    sign(t, jwtLib.SigningMethodRS256, "k3", validClaims(), key1),
+			wantErr:  ErrUnknownKey,
+		},
+		{
+			name:     "missing kid with several keys",
+			verifier: rsaVerifier,
+			token:    sign(t, jwtLib.SigningMethodRS256, "", validClaims(), key1),
+			wantErr:  ErrUnknownKey,
+		},
+		{
+			name:     "bad RS256 signature",
+			verifier: rsaVerifier,
+			token:    sign(t, jwtLib.SigningMethodRS256, "k1", validClaims(), other),
+			wantErr:  ErrInvalidSignature,
+		},
+		{
+			name:     "bad HS256 signature",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS256, "", validClaims(), []byte("another secret of thirty-two bytes")),
+			wantErr:  ErrInvalidSignature,
+		},
+		{
+			name:     "wrong issuer",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS256, "", withClaim("iss", "someone"), testSecret),
+			wantErr:  ErrInvalidIssuer,
+		},
+		{
+			name:     "missing audience",
+			verifier: hmacVerifier,
+			token:    sign(t, jwtLib.SigningMethodHS256, "", withClaim("aud", nil), testSecret),
+			wantErr:  ErrInvalidAudience,
+		},
+		{
+			name:     "malformed",
+			verifier: hmacVerifier,
+			token:    "not.a.token",
+			wantErr:  ErrMalformedToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.verifier.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if claims.Subject != "student-1" || len(claims.Roles) != 1 || claims.Roles[0] != "student" {
+				t.Errorf("Verify() claims = %+v", claims)
+			}
+
+			if classes := claims.Strings("classes"); len(classes) != 2 || classes[1] != "class-b" {
+				t.Errorf("Strings(classes) = %v", classes)
+			}
+		})
+	}
+}
+
+func TestHasKeys(t *testing.T) {
+	if New().HasKeys() {
+		t.Error("HasKeys() = true without a key")
+	}
+
+	if !New(WithHMACSecret(testSecret)).HasKeys() {
+		t.Error("HasKeys() = false with a secret")
+	}
+}